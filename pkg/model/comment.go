@@ -29,7 +29,16 @@
 
 package model
 
-import "time"
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
 
 type Comment struct {
 	Author string `json:"user"`
@@ -39,4 +48,256 @@ type Comment struct {
 	Date     time.Time `json:"date"`
 	ID       int       `json:"id"`
 	ParentID *int      `json:"parent"`
+
+	// Depth is the nesting level of the comment, where
+	// top-level comments have a depth of 0.
+	Depth int `json:"depth"`
+
+	// Dead is set when the comment has been killed
+	// or flagged by moderators or users.
+	Dead bool `json:"dead"`
+
+	// Points is the score of the comment, which HN only
+	// shows in some cases, and is nil otherwise.
+	Points *int `json:"points"`
+
+	// Links are the distinct hrefs of the anchors
+	// within the content, in order of appearance.
+	Links []string `json:"links"`
+
+	// Timestamp is the Unix time at which the comment was
+	// posted, as reported by HN or derived from Date.
+	Timestamp int64 `json:"timestamp"`
+
+	// Collapsed is set when the comment has been folded,
+	// hiding HiddenReplies replies beneath it.
+	Collapsed     bool `json:"collapsed"`
+	HiddenReplies int  `json:"hiddenReplies"`
+
+	// ReplyCount is the number of direct replies to the
+	// comment, as annotated by the markup where shown,
+	// and otherwise counted among the replies parsed
+	// alongside it, so replies beyond those are missed.
+	ReplyCount int `json:"replyCount"`
+
+	// FadeLevel is how faded the text of the comment is
+	// rendered, from 0 (full color) for comments in good
+	// standing to higher values for downvoted comments.
+	FadeLevel int `json:"fadeLevel"`
+
+	// RootID, NextID, and PrevID are the IDs of the
+	// comments that the navigation links of the comment
+	// point at: the top of its thread, and its next and
+	// previous siblings. Each is nil when its link is
+	// absent from the page.
+	RootID *int `json:"rootId"`
+	NextID *int `json:"nextId"`
+	PrevID *int `json:"prevId"`
+
+	// StoryID and StoryTitle identify the story that the
+	// comment was made on, when the comment is shown
+	// outside of its thread (e.g. in a user's history).
+	StoryID    *int   `json:"storyId"`
+	StoryTitle string `json:"storyTitle"`
+
+	// ReplyURL is the URL of the reply link of the
+	// comment, and is nil when the link is absent.
+	ReplyURL *url.URL `json:"replyUrl"`
+
+	// PermalinkURL is the URL of the page of the
+	// comment, as linked by its age, which points
+	// at the comment itself when shared.
+	PermalinkURL *url.URL `json:"permalinkUrl"`
+
+	// EditURL and DeleteURL are the links that
+	// edit and delete the comment, which are
+	// only displayed to its author.
+	EditURL   *url.URL `json:"editUrl"`
+	DeleteURL *url.URL `json:"deleteUrl"`
+
+	// AgeText is the relative age of the comment as
+	// rendered by HN (e.g. "3 hours ago").
+	AgeText string `json:"ageText"`
+
+	// RawDate is the raw date that could not be
+	// parsed by a lenient parser, in which case
+	// the date is left zero.
+	RawDate string `json:"rawDate"`
+
+	// Voteable is set when the comment carries an
+	// upvote arrow for the viewer of the page.
+	Voteable bool `json:"voteable"`
+
+	// RawHTML is the HTML of the content as it appears
+	// on the page, and is only kept on request.
+	RawHTML string `json:"rawHtml"`
+
+	// IsOP is set when the comment was written
+	// by the author of the item, also known as
+	// the original poster.
+	IsOP bool `json:"isOp"`
+
+	// WordCount is the number of words of the
+	// plain text of the content, and is zero
+	// for dead comments.
+	WordCount int `json:"wordCount"`
+}
+
+// PlainText returns the content of the comment with its tags stripped and
+// its entities unescaped. Paragraphs are separated by blank lines, line
+// breaks and the text of code blocks are preserved, and links are replaced
+// by their text.
+func (c *Comment) PlainText() string {
+	var builder strings.Builder
+
+	tokenizer := html.NewTokenizer(strings.NewReader(c.Content))
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(builder.String())
+		case html.TextToken:
+			builder.Write(tokenizer.Text())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+
+			switch string(name) {
+			case "p":
+				// the content may already separate
+				// its paragraphs with a blank line
+				if !strings.HasSuffix(builder.String(), "\n\n") {
+					builder.WriteString("\n\n")
+				}
+			case "br":
+				builder.WriteString("\n")
+			}
+		}
+	}
+}
+
+// Quotes returns the blocks of text that the comment quotes, in order. HN has no
+// markup for quotes, so users quote by starting a line with ">". A block is a run
+// of consecutive quoted lines of the plain text of the comment, which ends at the
+// first line that is not quoted, including the blank line between paragraphs. The
+// leading ">" of each line, along with the space after it, is removed, leaving any
+// nested quote markers in place. Returns nil if the comment quotes nothing.
+func (c *Comment) Quotes() []string {
+	var quotes []string
+
+	var block []string
+
+	flush := func() {
+		if len(block) > 0 {
+			quotes = append(quotes, strings.Join(block, "\n"))
+
+			block = nil
+		}
+	}
+
+	for _, line := range strings.Split(c.PlainText(), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if !strings.HasPrefix(trimmed, ">") {
+			flush()
+
+			continue
+		}
+
+		block = append(block, strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+	}
+
+	flush()
+
+	return quotes
+}
+
+// clone returns a deep copy of the comment, copying its pointers and slices.
+func (c Comment) clone() Comment {
+	c.ParentID = cloneInt(c.ParentID)
+	c.RootID = cloneInt(c.RootID)
+	c.NextID = cloneInt(c.NextID)
+	c.PrevID = cloneInt(c.PrevID)
+	c.Points = cloneInt(c.Points)
+	c.StoryID = cloneInt(c.StoryID)
+	c.Links = slices.Clone(c.Links)
+	c.ReplyURL = cloneURL(c.ReplyURL)
+	c.PermalinkURL = cloneURL(c.PermalinkURL)
+	c.EditURL = cloneURL(c.EditURL)
+	c.DeleteURL = cloneURL(c.DeleteURL)
+
+	return c
+}
+
+// Equal reports whether the comment and other hold the same values. Unlike
+// reflect-based equality, pointers are compared by the values they point to,
+// URLs by their string form, and dates by the instant they denote, regardless
+// of their location.
+func (c Comment) Equal(other Comment) bool {
+	return c.Author == other.Author &&
+		c.Content == other.Content &&
+		c.Date.Equal(other.Date) &&
+		c.ID == other.ID &&
+		equalInt(c.ParentID, other.ParentID) &&
+		equalInt(c.RootID, other.RootID) &&
+		equalInt(c.NextID, other.NextID) &&
+		equalInt(c.PrevID, other.PrevID) &&
+		c.Depth == other.Depth &&
+		c.Dead == other.Dead &&
+		equalInt(c.Points, other.Points) &&
+		slices.Equal(c.Links, other.Links) &&
+		c.Timestamp == other.Timestamp &&
+		c.Collapsed == other.Collapsed &&
+		c.HiddenReplies == other.HiddenReplies &&
+		c.ReplyCount == other.ReplyCount &&
+		c.FadeLevel == other.FadeLevel &&
+		equalInt(c.StoryID, other.StoryID) &&
+		c.StoryTitle == other.StoryTitle &&
+		equalURL(c.ReplyURL, other.ReplyURL) &&
+		equalURL(c.PermalinkURL, other.PermalinkURL) &&
+		equalURL(c.EditURL, other.EditURL) &&
+		equalURL(c.DeleteURL, other.DeleteURL) &&
+		c.AgeText == other.AgeText &&
+		c.RawDate == other.RawDate &&
+		c.Voteable == other.Voteable &&
+		c.RawHTML == other.RawHTML &&
+		c.IsOP == other.IsOP &&
+		c.WordCount == other.WordCount
+}
+
+// summaryLength specifies the number of characters of text
+// kept by the summaries of items and comments.
+const summaryLength = 60
+
+// String summarizes the comment for logging and debugging, such as
+// "#3067434 by dchest (depth 1): Nice, but the fibonacci...", with the plain
+// text of its content collapsed onto one line and truncated to 60 characters.
+// The author is omitted when unknown, as is the case for deleted comments.
+func (c Comment) String() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "#%d", c.ID)
+
+	if c.Author != "" {
+		fmt.Fprintf(&builder, " by %s", c.Author)
+	}
+
+	fmt.Fprintf(&builder, " (depth %d)", c.Depth)
+
+	if text := strings.Join(strings.Fields(c.PlainText()), " "); text != "" {
+		fmt.Fprintf(&builder, ": %s", truncate(text, summaryLength))
+	}
+
+	return builder.String()
+}
+
+// truncate returns the provided text cut down to at most the specified number
+// of characters, ending with an ellipsis when it was cut.
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+
+	return strings.TrimSpace(string(runes[:limit-3])) + "..."
 }
@@ -0,0 +1,248 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package model_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCommentPlainText tests that the content of a comment
+// is converted to plain text.
+func TestCommentPlainText(t *testing.T) {
+	tests := []struct {
+		Content  string
+		Text     string
+		Testname string
+	}{
+		{
+			Content:  "",
+			Text:     "",
+			Testname: "TestEmpty",
+		},
+		{
+			Content:  "<div>Is it any good? Yes.</div>",
+			Text:     "Is it any good? Yes.",
+			Testname: "TestSingleParagraph",
+		},
+		{
+			Content:  "<div>First.<p>Second, with <i>emphasis</i>.</p><p>Third.</p></div>",
+			Text:     "First.\n\nSecond, with emphasis.\n\nThird.",
+			Testname: "TestParagraphs",
+		},
+		{
+			Content:  "<div>First.\n\n<p>Second.</p>\n\n<pre><code>if x {\n  y()\n}</code></pre></div>",
+			Text:     "First.\n\nSecond.\n\nif x {\n  y()\n}",
+			Testname: "TestSeparatedParagraphs",
+		},
+		{
+			Content:  "<div>See <a>http://xkcd.com/386/</a> &amp; weep &#x27;now&#x27;</div>",
+			Text:     "See http://xkcd.com/386/ & weep 'now'",
+			Testname: "TestLinksAndEntities",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			comment := model.Comment{Content: test.Content}
+
+			assert.Equal(t, test.Text, comment.PlainText())
+		})
+	}
+}
+
+// TestCommentQuotes tests that the blocks of quoted text
+// of a comment are extracted.
+func TestCommentQuotes(t *testing.T) {
+	tests := []struct {
+		Content  string
+		Quotes   []string
+		Testname string
+	}{
+		{
+			Content:  "<div>No quotes here.<p>None at all.</p></div>",
+			Quotes:   nil,
+			Testname: "TestNoQuotes",
+		},
+		{
+			Content:  "<div>&gt; Is it any good?<p>Yes.</p></div>",
+			Quotes:   []string{"Is it any good?"},
+			Testname: "TestSingleQuote",
+		},
+		{
+			Content:  "<div>&gt; First point<p>Reply.</p><p>&gt;Second point</p><p>Another reply.</p></div>",
+			Quotes:   []string{"First point", "Second point"},
+			Testname: "TestSeparateQuotes",
+		},
+		{
+			Content:  "<div>&gt; A quote<br>&gt; spanning lines<p>Reply.</p></div>",
+			Quotes:   []string{"A quote\nspanning lines"},
+			Testname: "TestMultiLineQuote",
+		},
+		{
+			Content:  "<div>&gt; &gt; Nested<br>&gt; Outer<p>Reply.</p></div>",
+			Quotes:   []string{"> Nested\nOuter"},
+			Testname: "TestNestedQuote",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			comment := model.Comment{Content: test.Content}
+
+			assert.Equal(t, test.Quotes, comment.Quotes())
+		})
+	}
+}
+
+// TestCommentString tests that a comment is summarized on
+// one line, with its content truncated.
+func TestCommentString(t *testing.T) {
+	tests := []struct {
+		Comment  model.Comment
+		Expected string
+		Testname string
+	}{
+		{
+			Comment:  model.Comment{ID: 3067434, Author: "dchest", Depth: 1, Content: "<div>Is it any good?<p>Yes.</p></div>"},
+			Expected: "#3067434 by dchest (depth 1): Is it any good? Yes.",
+			Testname: "TestShortContent",
+		},
+		{
+			Comment: model.Comment{
+				ID:      3067519,
+				Author:  "pg",
+				Content: "<div>The quick brown fox jumps over the lazy dog, again and again and again.</div>",
+			},
+			Expected: "#3067519 by pg (depth 0): The quick brown fox jumps over the lazy dog, again and ag...",
+			Testname: "TestLongContent",
+		},
+		{
+			Comment:  model.Comment{ID: 3067520, Depth: 2},
+			Expected: "#3067520 (depth 2)",
+			Testname: "TestDeleted",
+		},
+		{
+			Comment:  model.Comment{},
+			Expected: "#0 (depth 0)",
+			Testname: "TestZeroValue",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			assert.Equal(t, test.Expected, test.Comment.String())
+		})
+	}
+}
+
+// TestCommentEqual tests that comments are compared by value, through
+// their pointers and URLs, and that any differing field is reported.
+func TestCommentEqual(t *testing.T) {
+	newComment := func() model.Comment {
+		parentID := 3067434
+
+		return model.Comment{
+			Author:   "glenjamin",
+			Date:     time.Date(2011, time.October, 3, 19, 3, 20, 0, time.UTC),
+			ID:       3067519,
+			ParentID: &parentID,
+			Links:    []string{"https://nodejs.org"},
+			ReplyURL: &url.URL{Scheme: "https", Host: "news.ycombinator.com", Path: "/reply", RawQuery: "id=3067519"},
+		}
+	}
+
+	replyURL, err := url.Parse("https://news.ycombinator.com/reply?id=3067519")
+
+	assert.Nil(t, err)
+
+	tests := []struct {
+		Mutate   func(c *model.Comment)
+		Expected bool
+		Testname string
+	}{
+		{
+			Mutate:   func(c *model.Comment) {},
+			Expected: true,
+			Testname: "TestIdentical",
+		},
+		{
+			Mutate:   func(c *model.Comment) { c.ReplyURL = replyURL },
+			Expected: true,
+			Testname: "TestReparsedURL",
+		},
+		{
+			Mutate:   func(c *model.Comment) { c.Date = c.Date.In(time.FixedZone("PDT", -7*60*60)) },
+			Expected: true,
+			Testname: "TestDateLocation",
+		},
+		{
+			Mutate:   func(c *model.Comment) { *c.ParentID = 1 },
+			Expected: false,
+			Testname: "TestParentID",
+		},
+		{
+			Mutate:   func(c *model.Comment) { c.ParentID = nil },
+			Expected: false,
+			Testname: "TestNilParentID",
+		},
+		{
+			Mutate:   func(c *model.Comment) { c.ReplyURL = nil },
+			Expected: false,
+			Testname: "TestNilURL",
+		},
+		{
+			Mutate:   func(c *model.Comment) { c.Links = append(c.Links, "https://example.com") },
+			Expected: false,
+			Testname: "TestLinks",
+		},
+		{
+			Mutate:   func(c *model.Comment) { c.WordCount = 12 },
+			Expected: false,
+			Testname: "TestWordCount",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			other := newComment()
+
+			test.Mutate(&other)
+
+			assert.Equal(t, test.Expected, newComment().Equal(other))
+
+			assert.Equal(t, test.Expected, other.Equal(newComment()))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package model
+
+import "slices"
+
+// CommentNode is a comment within the reply tree of an item,
+// along with the replies made to it.
+type CommentNode struct {
+	Comment  Comment        `json:"comment"`
+	Children []*CommentNode `json:"children"`
+}
+
+// Flatten returns the comment of the node followed by those of its replies, in
+// pre-order, which is the order in which HN displays them. The replies of the
+// node are thus flattened back into a slice, such as after pruning the tree.
+func (n *CommentNode) Flatten() []Comment {
+	return n.appendFlattened(nil)
+}
+
+// appendFlattened appends the comment of the node followed by those of its
+// replies, in pre-order, to the provided slice, and returns the result.
+func (n *CommentNode) appendFlattened(comments []Comment) []Comment {
+	if n == nil {
+		return comments
+	}
+
+	comments = append(comments, n.Comment)
+
+	for _, child := range n.Children {
+		comments = child.appendFlattened(comments)
+	}
+
+	return comments
+}
+
+// clone returns a deep copy of the node, its comment, and its replies.
+func (n *CommentNode) clone() *CommentNode {
+	if n == nil {
+		return nil
+	}
+
+	return &CommentNode{Comment: n.Comment.clone(), Children: cloneCommentNodes(n.Children)}
+}
+
+// cloneCommentNodes returns a deep copy of the provided nodes, or nil
+// if the slice is nil.
+func cloneCommentNodes(nodes []*CommentNode) []*CommentNode {
+	if nodes == nil {
+		return nil
+	}
+
+	clones := make([]*CommentNode, len(nodes))
+
+	for i, node := range nodes {
+		clones[i] = node.clone()
+	}
+
+	return clones
+}
+
+// equal reports whether the node and other hold equal comments, as compared
+// with Comment.Equal, and equal replies, in the same order. Two nil nodes are
+// equal.
+func (n *CommentNode) equal(other *CommentNode) bool {
+	if n == nil || other == nil {
+		return n == other
+	}
+
+	return n.Comment.Equal(other.Comment) && slices.EqualFunc(n.Children, other.Children, (*CommentNode).equal)
+}
@@ -0,0 +1,70 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCommentNodeFlatten tests that a reply tree is flattened
+// in pre-order, the order in which HN displays comments.
+func TestCommentNodeFlatten(t *testing.T) {
+	root := &model.CommentNode{
+		Comment: model.Comment{ID: 1},
+		Children: []*model.CommentNode{
+			{
+				Comment: model.Comment{ID: 2, Depth: 1},
+				Children: []*model.CommentNode{
+					{Comment: model.Comment{ID: 3, Depth: 2}},
+				},
+			},
+			{Comment: model.Comment{ID: 4, Depth: 1}},
+		},
+	}
+
+	var ids []int
+
+	for _, comment := range root.Flatten() {
+		ids = append(ids, comment.ID)
+	}
+
+	assert.Equal(t, []int{1, 2, 3, 4}, ids)
+
+	leaf := &model.CommentNode{Comment: model.Comment{ID: 5}}
+
+	assert.Equal(t, []model.Comment{{ID: 5}}, leaf.Flatten())
+
+	var empty *model.CommentNode
+
+	assert.Nil(t, empty.Flatten())
+}
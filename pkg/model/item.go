@@ -29,7 +29,32 @@
 
 package model
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrItemMismatch is reported when merging the comments of
+// two different items.
+var ErrItemMismatch = errors.New("model: item mismatch")
+
+// yearHintRegex matches the year that HN appends to the title
+// of a story first published in a past year, such as "(2019)".
+var yearHintRegex = regexp.MustCompile(`\((\d{4})\)\s*$`)
+
+// dupeMarkerRegex matches the "[dupe]" marker that moderators
+// add to the title of a story that duplicates another.
+var dupeMarkerRegex = regexp.MustCompile(`(?i)\[dupe\]`)
+
+// ErrInvalidItem is reported for each inconsistency found
+// when validating an item.
+var ErrInvalidItem = errors.New("model: invalid item")
 
 type Item struct {
 	Title    Title     `json:"title"`
@@ -38,4 +63,437 @@ type Item struct {
 	ID       int       `json:"id"`
 	Points   int       `json:"points"`
 	Comments []Comment `json:"comment"`
+
+	// Text is the HTML body of a self-post (e.g. Ask HN),
+	// and is empty for link submissions.
+	Text string `json:"text"`
+
+	// CommentCount is the number of comments reported by
+	// the subline, which may exceed len(Comments) when the
+	// thread is paginated.
+	CommentCount int `json:"commentCount"`
+
+	// TopLevelCommentCount is the number of the
+	// parsed comments that reply to the item
+	// directly, rather than to another comment.
+	TopLevelCommentCount int `json:"topLevelCommentCount"`
+
+	// Domain is the site string displayed next to the
+	// title, and is empty for self-posts.
+	Domain string `json:"domain"`
+
+	// SecondaryLinks are the links of the title beyond
+	// the main one, such as the demo of a Show HN, and
+	// are nil when the title has a single link.
+	SecondaryLinks []*url.URL `json:"secondaryLinks"`
+
+	// IsJob is set for job postings, which have
+	// neither a score nor an author.
+	IsJob bool `json:"isJob"`
+
+	// Poll holds the options of a poll, and is
+	// nil for any other kind of item.
+	Poll *Poll `json:"poll"`
+
+	// Timestamp is the Unix time at which the item was
+	// posted, as reported by HN or derived from Date.
+	Timestamp int64 `json:"timestamp"`
+
+	// NextPage is the URL of the next page of comments,
+	// and is nil on the last page.
+	NextPage *url.URL `json:"nextPage"`
+
+	// Found records which of the fields were found
+	// while parsing the page.
+	Found FieldsFound `json:"found"`
+
+	// CommentsTruncated is set when some of the comments
+	// on the page were skipped during parsing.
+	CommentsTruncated bool `json:"commentsTruncated"`
+
+	// CommentRoots is the reply tree of the comments, built
+	// while parsing when requested, and is nil otherwise.
+	CommentRoots []*CommentNode `json:"commentRoots"`
+
+	// Description and ImageURL are taken from the Open
+	// Graph metadata of archived or proxied pages, and
+	// are empty for pages served by HN.
+	Description string   `json:"description"`
+	ImageURL    *url.URL `json:"imageUrl"`
+
+	// Rank is the position of the item on a listing
+	// page, such as the front page, and is 0 for items
+	// parsed from their own page.
+	Rank int `json:"rank"`
+
+	// AgeText is the relative age of the item as
+	// rendered by HN (e.g. "3 hours ago").
+	AgeText string `json:"ageText"`
+
+	// RawDate is the raw date that could not be
+	// parsed by a lenient parser, in which case
+	// the date is left zero.
+	RawDate string `json:"rawDate"`
+
+	// Voteable is set when the item carries an
+	// upvote arrow for the viewer of the page.
+	Voteable bool `json:"voteable"`
+
+	// RelatedDiscussions are the links to past
+	// discussions of a reposted story, if any.
+	RelatedDiscussions []*url.URL `json:"relatedDiscussions"`
+
+	// Kind is the kind of the item, as inferred
+	// from its page.
+	Kind Kind `json:"kind"`
+
+	// Participants are the distinct authors of the
+	// item and its comments, in order of appearance.
+	Participants []string `json:"participants"`
+
+	// HideURL is the link that hides the item
+	// from the viewer, if displayed.
+	HideURL *url.URL `json:"hideUrl"`
+
+	// FlagURL is the link that flags the item,
+	// if displayed to the viewer.
+	FlagURL *url.URL `json:"flagUrl"`
+
+	// EditURL and DeleteURL are the links that
+	// edit and delete the item, which are only
+	// displayed to its author.
+	EditURL   *url.URL `json:"editUrl"`
+	DeleteURL *url.URL `json:"deleteUrl"`
+
+	// Upvoted is set when the viewer of the page
+	// has already upvoted the item.
+	Upvoted bool `json:"upvoted"`
+
+	// PageTitle is the title of the page, less
+	// the " | Hacker News" suffix, which is the
+	// name of the item on its own page.
+	PageTitle string `json:"pageTitle"`
+
+	// FocusedCommentID is the ID of the comment
+	// that the page focuses on when it is the
+	// permalink page of a comment, in which case
+	// the item is the story of the comment and
+	// the focused comment leads its comments.
+	FocusedCommentID *int `json:"focusedCommentId"`
+
+	// FaviconURL is the icon of the site of the
+	// item, if rendered beside its vote arrows.
+	FaviconURL *url.URL `json:"faviconUrl"`
+}
+
+// FieldsFound records which of the fields of an Item were found
+// while parsing the page, as opposed to being left zeroed.
+type FieldsFound struct {
+	Title  bool `json:"title"`
+	ID     bool `json:"id"`
+	Score  bool `json:"score"`
+	Date   bool `json:"date"`
+	Author bool `json:"author"`
+}
+
+// String summarizes the item for logging and debugging, such as
+// "#3067403 "Node-fib: Fast non-blocking fibonacci server" by dchest, 194 pts,
+// 118 comments", with its title truncated to 60 characters. The author is
+// omitted when unknown, as is the case for job postings.
+func (item Item) String() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "#%d %q", item.ID, truncate(item.Title.Name, summaryLength))
+
+	if item.Author != "" {
+		fmt.Fprintf(&builder, " by %s", item.Author)
+	}
+
+	fmt.Fprintf(&builder, ", %d pts, %d comments", item.Points, item.CommentCount)
+
+	return builder.String()
+}
+
+// Age returns the time elapsed between the submission of the item and the
+// provided time. Returns zero if the date of the item is unknown or lies after
+// the provided time, such as under clock skew.
+func (item *Item) Age(now time.Time) time.Duration {
+	if item.Date.IsZero() || !now.After(item.Date) {
+		return 0
+	}
+
+	return now.Sub(item.Date)
+}
+
+// PointsPerHour returns the points of the item divided by its age in hours at
+// the provided time, as used by ranking heuristics. Returns zero if the age of
+// the item is zero, rather than dividing by it.
+func (item *Item) PointsPerHour(now time.Time) float64 {
+	age := item.Age(now)
+
+	if age <= 0 {
+		return 0
+	}
+
+	return float64(item.Points) / age.Hours()
+}
+
+// YearHint returns the year that the title of the item ends with, following the HN
+// convention of marking stories first published in a past year, such as "(2019)".
+// Returns false if the title holds no such year.
+func (item *Item) YearHint() (int, bool) {
+	match := yearHintRegex.FindStringSubmatch(item.Title.Name)
+
+	if match == nil {
+		return 0, false
+	}
+
+	year, err := strconv.Atoi(match[1])
+
+	if err != nil {
+		return 0, false
+	}
+
+	return year, true
+}
+
+// IsDupeFlagged reports whether the title of the item carries the "[dupe]" marker
+// that moderators add to a story that duplicates another, in any case.
+func (item *Item) IsDupeFlagged() bool {
+	return dupeMarkerRegex.MatchString(item.Title.Name)
+}
+
+// Clone returns a deep copy of the item, which shares no memory with the item:
+// the URLs, the comments along with their pointers and slices, the poll, and the
+// other slices and pointers of the item are all copied, so that either copy can
+// be mutated without affecting the other. Returns nil if the item is nil.
+func (item *Item) Clone() *Item {
+	if item == nil {
+		return nil
+	}
+
+	clone := *item
+
+	clone.Title.Reference = cloneURL(item.Title.Reference)
+	clone.NextPage = cloneURL(item.NextPage)
+	clone.ImageURL = cloneURL(item.ImageURL)
+	clone.HideURL = cloneURL(item.HideURL)
+	clone.FlagURL = cloneURL(item.FlagURL)
+	clone.EditURL = cloneURL(item.EditURL)
+	clone.DeleteURL = cloneURL(item.DeleteURL)
+	clone.SecondaryLinks = cloneURLs(item.SecondaryLinks)
+	clone.RelatedDiscussions = cloneURLs(item.RelatedDiscussions)
+	clone.Participants = slices.Clone(item.Participants)
+	clone.FocusedCommentID = cloneInt(item.FocusedCommentID)
+	clone.FaviconURL = cloneURL(item.FaviconURL)
+
+	if item.Poll != nil {
+		clone.Poll = &Poll{Options: slices.Clone(item.Poll.Options)}
+	}
+
+	if item.Comments != nil {
+		clone.Comments = make([]Comment, len(item.Comments))
+
+		for i, comment := range item.Comments {
+			clone.Comments[i] = comment.clone()
+		}
+	}
+
+	clone.CommentRoots = cloneCommentNodes(item.CommentRoots)
+
+	return &clone
+}
+
+// cloneURL returns a copy of the provided URL, or nil if it is nil.
+func cloneURL(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+
+	clone := *u
+
+	return &clone
+}
+
+// cloneURLs returns a copy of the provided URLs, each of which is
+// copied in turn, or nil if the slice is nil.
+func cloneURLs(urls []*url.URL) []*url.URL {
+	if urls == nil {
+		return nil
+	}
+
+	clones := make([]*url.URL, len(urls))
+
+	for i, u := range urls {
+		clones[i] = cloneURL(u)
+	}
+
+	return clones
+}
+
+// cloneInt returns a copy of the provided integer, or nil if it is nil.
+func cloneInt(n *int) *int {
+	if n == nil {
+		return nil
+	}
+
+	clone := *n
+
+	return &clone
+}
+
+// Equal reports whether the item and other hold the same values, including
+// those of their comments, which are compared with Comment.Equal. Unlike
+// reflect-based equality, pointers are compared by the values they point to,
+// URLs by their string form, and dates by the instant they denote, regardless
+// of their location. Two nil items are equal.
+func (item *Item) Equal(other *Item) bool {
+	if item == nil || other == nil {
+		return item == other
+	}
+
+	return item.Title.Name == other.Title.Name &&
+		equalURL(item.Title.Reference, other.Title.Reference) &&
+		item.Author == other.Author &&
+		item.Date.Equal(other.Date) &&
+		item.ID == other.ID &&
+		item.Points == other.Points &&
+		slices.EqualFunc(item.Comments, other.Comments, Comment.Equal) &&
+		item.Text == other.Text &&
+		item.CommentCount == other.CommentCount &&
+		item.TopLevelCommentCount == other.TopLevelCommentCount &&
+		item.Domain == other.Domain &&
+		slices.EqualFunc(item.SecondaryLinks, other.SecondaryLinks, equalURL) &&
+		item.IsJob == other.IsJob &&
+		equalPoll(item.Poll, other.Poll) &&
+		item.Timestamp == other.Timestamp &&
+		equalURL(item.NextPage, other.NextPage) &&
+		item.Found == other.Found &&
+		item.CommentsTruncated == other.CommentsTruncated &&
+		slices.EqualFunc(item.CommentRoots, other.CommentRoots, (*CommentNode).equal) &&
+		item.Description == other.Description &&
+		equalURL(item.ImageURL, other.ImageURL) &&
+		item.Rank == other.Rank &&
+		item.AgeText == other.AgeText &&
+		item.RawDate == other.RawDate &&
+		item.Voteable == other.Voteable &&
+		slices.EqualFunc(item.RelatedDiscussions, other.RelatedDiscussions, equalURL) &&
+		item.Kind == other.Kind &&
+		slices.Equal(item.Participants, other.Participants) &&
+		equalURL(item.HideURL, other.HideURL) &&
+		equalURL(item.FlagURL, other.FlagURL) &&
+		equalURL(item.EditURL, other.EditURL) &&
+		equalURL(item.DeleteURL, other.DeleteURL) &&
+		item.Upvoted == other.Upvoted &&
+		item.PageTitle == other.PageTitle &&
+		equalInt(item.FocusedCommentID, other.FocusedCommentID) &&
+		equalURL(item.FaviconURL, other.FaviconURL)
+}
+
+// equalURL reports whether the provided URLs are both nil, or
+// have the same string form.
+func equalURL(a *url.URL, b *url.URL) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.String() == b.String()
+}
+
+// equalInt reports whether the provided integers are both nil,
+// or hold the same value.
+func equalInt(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// equalPoll reports whether the provided polls are both nil, or
+// have the same options.
+func equalPoll(a *Poll, b *Poll) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return slices.Equal(a.Options, b.Options)
+}
+
+// MergeComments appends the comments of other, such as a subsequent page
+// of the same thread, to the comments of the item, skipping comments whose
+// ID is already present. A nil other holds no comments to merge. Returns an
+// error wrapping ErrItemMismatch if the two items do not share the same ID.
+func (item *Item) MergeComments(other *Item) error {
+	if other == nil {
+		return nil
+	}
+
+	if item.ID != other.ID {
+		return fmt.Errorf("%w: cannot merge comments of item %d into item %d", ErrItemMismatch, other.ID, item.ID)
+	}
+
+	seen := make(map[int]struct{}, len(item.Comments))
+
+	for _, comment := range item.Comments {
+		seen[comment.ID] = struct{}{}
+	}
+
+	for _, comment := range other.Comments {
+		if _, ok := seen[comment.ID]; ok {
+			continue
+		}
+
+		seen[comment.ID] = struct{}{}
+
+		item.Comments = append(item.Comments, comment)
+	}
+
+	return nil
+}
+
+// Validate checks the internal consistency of the item, such as one produced by
+// a partial parse: its ID and date must be set, its title must be set unless it
+// is a job posting, and the parent of each of its comments, if any, must be either
+// another of its comments or the item itself, save for that of a focused comment.
+// Returns nil if the item is valid, and otherwise an error joining one error
+// wrapping ErrInvalidItem per problem found.
+func (item *Item) Validate() error {
+	var errs []error
+
+	if item.ID == 0 {
+		errs = append(errs, fmt.Errorf("%w: missing id", ErrInvalidItem))
+	}
+
+	if item.Title.Name == "" && !item.IsJob {
+		errs = append(errs, fmt.Errorf("%w: missing title", ErrInvalidItem))
+	}
+
+	if item.Date.IsZero() {
+		errs = append(errs, fmt.Errorf("%w: missing date", ErrInvalidItem))
+	}
+
+	ids := make(map[int]struct{}, len(item.Comments))
+
+	for _, comment := range item.Comments {
+		ids[comment.ID] = struct{}{}
+	}
+
+	for _, comment := range item.Comments {
+		if comment.ParentID == nil || *comment.ParentID == item.ID {
+			continue
+		}
+
+		// the parent of a focused comment lies
+		// beyond the page that focuses on it
+		if item.FocusedCommentID != nil && comment.ID == *item.FocusedCommentID {
+			continue
+		}
+
+		if _, ok := ids[*comment.ParentID]; !ok {
+			errs = append(errs, fmt.Errorf("%w: comment %d has unknown parent %d", ErrInvalidItem, comment.ID, *comment.ParentID))
+		}
+	}
+
+	return errors.Join(errs...)
 }
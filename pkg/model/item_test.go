@@ -0,0 +1,475 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package model_test
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMergeComments tests that the comments of a subsequent
+// page are appended without duplicates.
+func TestMergeComments(t *testing.T) {
+	item := &model.Item{
+		ID:       3067403,
+		Comments: []model.Comment{{ID: 1}, {ID: 2}},
+	}
+
+	other := &model.Item{
+		ID:       3067403,
+		Comments: []model.Comment{{ID: 2}, {ID: 3}, {ID: 3}},
+	}
+
+	err := item.MergeComments(other)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, []model.Comment{{ID: 1}, {ID: 2}, {ID: 3}}, item.Comments)
+}
+
+// TestMergeCommentsMismatch tests that the comments of
+// a different item are not merged.
+func TestMergeCommentsMismatch(t *testing.T) {
+	item := &model.Item{
+		ID:       3067403,
+		Comments: []model.Comment{{ID: 1}},
+	}
+
+	err := item.MergeComments(&model.Item{ID: 8100000, Comments: []model.Comment{{ID: 2}}})
+
+	assert.ErrorIs(t, err, model.ErrItemMismatch)
+
+	assert.Equal(t, []model.Comment{{ID: 1}}, item.Comments)
+}
+
+// TestMergeCommentsNil tests that merging a nil item
+// leaves the comments untouched.
+func TestMergeCommentsNil(t *testing.T) {
+	item := &model.Item{
+		ID:       3067403,
+		Comments: []model.Comment{{ID: 1}},
+	}
+
+	assert.Nil(t, item.MergeComments(nil))
+
+	assert.Equal(t, []model.Comment{{ID: 1}}, item.Comments)
+}
+
+// TestValidate tests that a consistent item is valid, and
+// that every inconsistency of an invalid item is reported.
+func TestValidate(t *testing.T) {
+	parentID := 1
+
+	storyID := 3067403
+
+	item := &model.Item{
+		Title: model.Title{Name: "Node-fib: Fast non-blocking fibonacci server"},
+		ID:    3067403,
+		Date:  time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC),
+		Comments: []model.Comment{
+			{ID: 1, ParentID: &storyID},
+			{ID: 2, ParentID: &parentID},
+			{ID: 3},
+		},
+	}
+
+	assert.Nil(t, item.Validate())
+
+	// job postings have no title requirement
+	job := &model.Item{ID: 41234567, Date: item.Date, IsJob: true}
+
+	assert.Nil(t, job.Validate())
+
+	unknownID := 42
+
+	invalid := &model.Item{
+		Comments: []model.Comment{{ID: 2, ParentID: &unknownID}},
+	}
+
+	err := invalid.Validate()
+
+	assert.ErrorIs(t, err, model.ErrInvalidItem)
+
+	message := err.Error()
+
+	assert.True(t, strings.Contains(message, "missing id"))
+
+	assert.True(t, strings.Contains(message, "missing title"))
+
+	assert.True(t, strings.Contains(message, "missing date"))
+
+	assert.True(t, strings.Contains(message, "comment 2 has unknown parent 42"))
+}
+
+// TestItemString tests that an item is summarized on one
+// line, including when it has no author or no fields set.
+func TestItemString(t *testing.T) {
+	tests := []struct {
+		Item     model.Item
+		Expected string
+		Testname string
+	}{
+		{
+			Item: model.Item{
+				Title:        model.Title{Name: "Node-fib: Fast non-blocking fibonacci server"},
+				ID:           3067403,
+				Author:       "dchest",
+				Points:       194,
+				CommentCount: 118,
+			},
+			Expected: `#3067403 "Node-fib: Fast non-blocking fibonacci server" by dchest, 194 pts, 118 comments`,
+			Testname: "TestStory",
+		},
+		{
+			Item: model.Item{
+				Title: model.Title{Name: strings.Repeat("a", 70)},
+				ID:    41234567,
+				IsJob: true,
+			},
+			Expected: `#41234567 "` + strings.Repeat("a", 57) + `...", 0 pts, 0 comments`,
+			Testname: "TestLongTitle",
+		},
+		{
+			Item:     model.Item{},
+			Expected: `#0 "", 0 pts, 0 comments`,
+			Testname: "TestZeroValue",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			assert.Equal(t, test.Expected, test.Item.String())
+		})
+	}
+
+	// items are summarized whether printed by value or by pointer
+	assert.Equal(t, "#0 \"\", 0 pts, 0 comments", fmt.Sprint(model.Item{}))
+
+	assert.Equal(t, "#0 \"\", 0 pts, 0 comments", fmt.Sprint(&model.Item{}))
+
+	assert.Equal(t, "<nil>", fmt.Sprint((*model.Item)(nil)))
+}
+
+// TestItemAge tests that the age and the points per hour of an
+// item are derived from its date, and are zero when its date
+// is unknown or lies ahead.
+func TestItemAge(t *testing.T) {
+	date := time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC)
+
+	tests := []struct {
+		Date          time.Time
+		Now           time.Time
+		Age           time.Duration
+		PointsPerHour float64
+		Testname      string
+	}{
+		{
+			Date:          date,
+			Now:           date.Add(4 * time.Hour),
+			Age:           4 * time.Hour,
+			PointsPerHour: 48.5,
+			Testname:      "TestHours",
+		},
+		{
+			Date:          date,
+			Now:           date.Add(30 * time.Minute),
+			Age:           30 * time.Minute,
+			PointsPerHour: 388,
+			Testname:      "TestMinutes",
+		},
+		{
+			Date:          date,
+			Now:           date,
+			Age:           0,
+			PointsPerHour: 0,
+			Testname:      "TestZeroAge",
+		},
+		{
+			Date:          date,
+			Now:           date.Add(-time.Minute),
+			Age:           0,
+			PointsPerHour: 0,
+			Testname:      "TestFutureDate",
+		},
+		{
+			Date:          time.Time{},
+			Now:           date,
+			Age:           0,
+			PointsPerHour: 0,
+			Testname:      "TestUnknownDate",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			item := &model.Item{Date: test.Date, Points: 194}
+
+			assert.Equal(t, test.Age, item.Age(test.Now))
+
+			assert.Equal(t, test.PointsPerHour, item.PointsPerHour(test.Now))
+		})
+	}
+}
+
+// TestClone tests that a clone of an item equals the item, and that
+// mutating the clone, down to the pointers and slices of its comments,
+// leaves the item untouched.
+func TestClone(t *testing.T) {
+	newItem := func() *model.Item {
+		parentID := 3067434
+
+		points := 4
+
+		focusedID := 3067434
+
+		return &model.Item{
+			Title: model.Title{
+				Name:      "Node-fib: Fast non-blocking fibonacci server",
+				Reference: &url.URL{Scheme: "https", Host: "github.com", Path: "/glenjamin/node-fib"},
+			},
+			ID:                 3067403,
+			NextPage:           &url.URL{Scheme: "https", Host: "news.ycombinator.com", Path: "/item", RawQuery: "id=3067403&p=2"},
+			SecondaryLinks:     []*url.URL{{Scheme: "https", Host: "example.com", Path: "/demo"}},
+			RelatedDiscussions: []*url.URL{{Scheme: "https", Host: "news.ycombinator.com", Path: "/item", RawQuery: "id=1"}},
+			Participants:       []string{"dchest", "glenjamin"},
+			FocusedCommentID:   &focusedID,
+			Poll:               &model.Poll{Options: []model.PollOption{{Text: "Yes", Points: 3}}},
+			Comments: []model.Comment{
+				{ID: 3067434, Author: "glenjamin"},
+				{
+					ID:       3067519,
+					ParentID: &parentID,
+					Points:   &points,
+					Links:    []string{"https://nodejs.org"},
+					ReplyURL: &url.URL{Scheme: "https", Host: "news.ycombinator.com", Path: "/reply", RawQuery: "id=3067519"},
+				},
+			},
+			CommentRoots: []*model.CommentNode{
+				{
+					Comment:  model.Comment{ID: 3067434, Author: "glenjamin"},
+					Children: []*model.CommentNode{{Comment: model.Comment{ID: 3067519, ParentID: &parentID}}},
+				},
+			},
+		}
+	}
+
+	item := newItem()
+
+	clone := item.Clone()
+
+	assert.Equal(t, item, clone)
+
+	clone.Title.Reference.Host = "example.com"
+	clone.NextPage.RawQuery = "id=3067403&p=3"
+	clone.SecondaryLinks[0].Path = "/other"
+	clone.RelatedDiscussions[0].RawQuery = "id=2"
+	clone.Participants[0] = "someone"
+	*clone.FocusedCommentID = 1
+	clone.Poll.Options[0].Points = 100
+	clone.Comments[0].Author = "someone"
+	*clone.Comments[1].ParentID = 1
+	*clone.Comments[1].Points = 100
+	clone.Comments[1].Links[0] = "https://example.com"
+	clone.Comments[1].ReplyURL.RawQuery = "id=1"
+	clone.CommentRoots[0].Comment.Author = "someone"
+	*clone.CommentRoots[0].Children[0].Comment.ParentID = 1
+	clone.CommentRoots[0].Children = nil
+
+	assert.Equal(t, newItem(), item)
+
+	assert.Nil(t, (*model.Item)(nil).Clone())
+}
+
+// TestItemEqual tests that items are compared by value, through their
+// pointers, URLs, and comments, and that any differing field is reported.
+func TestItemEqual(t *testing.T) {
+	newItem := func() *model.Item {
+		return &model.Item{
+			Title: model.Title{
+				Name:      "Node-fib: Fast non-blocking fibonacci server",
+				Reference: &url.URL{Scheme: "https", Host: "github.com", Path: "/glenjamin/node-fib"},
+			},
+			ID:       3067403,
+			Date:     time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC),
+			Poll:     &model.Poll{Options: []model.PollOption{{Text: "Yes", Points: 3}}},
+			Comments: []model.Comment{{ID: 3067434, Author: "glenjamin"}},
+			CommentRoots: []*model.CommentNode{
+				{
+					Comment:  model.Comment{ID: 3067434, Author: "glenjamin"},
+					Children: []*model.CommentNode{{Comment: model.Comment{ID: 3067519}}},
+				},
+			},
+		}
+	}
+
+	reference, err := url.Parse("https://github.com/glenjamin/node-fib")
+
+	assert.Nil(t, err)
+
+	tests := []struct {
+		Mutate   func(item *model.Item)
+		Expected bool
+		Testname string
+	}{
+		{
+			Mutate:   func(item *model.Item) {},
+			Expected: true,
+			Testname: "TestIdentical",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.Title.Reference = reference },
+			Expected: true,
+			Testname: "TestReparsedURL",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.Title.Reference = nil },
+			Expected: false,
+			Testname: "TestNilURL",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.Poll.Options[0].Points = 4 },
+			Expected: false,
+			Testname: "TestPoll",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.Comments[0].Author = "dchest" },
+			Expected: false,
+			Testname: "TestComment",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.Comments = nil },
+			Expected: false,
+			Testname: "TestComments",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.CommentRoots[0].Children[0].Comment.Author = "dchest" },
+			Expected: false,
+			Testname: "TestCommentTree",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.CommentRoots[0].Children = nil },
+			Expected: false,
+			Testname: "TestCommentTreeShape",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.FaviconURL = reference },
+			Expected: false,
+			Testname: "TestFavicon",
+		},
+		{
+			Mutate:   func(item *model.Item) { item.Found.Title = true },
+			Expected: false,
+			Testname: "TestFound",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			other := newItem()
+
+			test.Mutate(other)
+
+			assert.Equal(t, test.Expected, newItem().Equal(other))
+
+			assert.Equal(t, test.Expected, other.Equal(newItem()))
+		})
+	}
+
+	item := newItem()
+
+	assert.True(t, item.Equal(item.Clone()))
+
+	assert.False(t, item.Equal(nil))
+
+	assert.True(t, (*model.Item)(nil).Equal(nil))
+}
+
+// TestTitleConventions tests that the year hint and the dupe marker
+// are recognized in titles, and only at their conventional places.
+func TestTitleConventions(t *testing.T) {
+	tests := []struct {
+		Title    string
+		Year     int
+		HasYear  bool
+		Dupe     bool
+		Testname string
+	}{
+		{
+			Title:    "The Mythical Man-Month (1975)",
+			Year:     1975,
+			HasYear:  true,
+			Testname: "TestYearHint",
+		},
+		{
+			Title:    "How the 2019 outage unfolded",
+			Testname: "TestYearInTitle",
+		},
+		{
+			Title:    "Show HN: Tinyq (2024) – a durable job queue",
+			Testname: "TestYearNotTrailing",
+		},
+		{
+			Title:    "[dupe] Node-fib: Fast non-blocking fibonacci server (2011)",
+			Year:     2011,
+			HasYear:  true,
+			Dupe:     true,
+			Testname: "TestDupeWithYear",
+		},
+		{
+			Title:    "Node-fib: Fast non-blocking fibonacci server [Dupe]",
+			Dupe:     true,
+			Testname: "TestDupeCase",
+		},
+		{
+			Title:    "",
+			Testname: "TestEmpty",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			item := &model.Item{Title: model.Title{Name: test.Title}}
+
+			year, ok := item.YearHint()
+
+			assert.Equal(t, test.HasYear, ok)
+
+			assert.Equal(t, test.Year, year)
+
+			assert.Equal(t, test.Dupe, item.IsDupeFlagged())
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package model
+
+// Kind is the kind of an HN item, as inferred from its page.
+type Kind string
+
+const (
+	// KindStory is a link submission, or any
+	// story that is not of a more specific kind.
+	KindStory Kind = "story"
+
+	// KindComment is a comment viewed on its own page
+	// when the story it was posted on is unknown;
+	// otherwise, the page takes the kind of the story.
+	KindComment Kind = "comment"
+
+	// KindJob is a job posting, which has neither
+	// a score nor an author.
+	KindJob Kind = "job"
+
+	// KindPoll is a poll, which has options.
+	KindPoll Kind = "poll"
+
+	// KindAsk is an "Ask HN:" story.
+	KindAsk Kind = "ask"
+
+	// KindShow is a "Show HN:" story.
+	KindShow Kind = "show"
+)
@@ -29,9 +29,59 @@
 
 package model
 
-import "net/url"
+import (
+	"encoding/json"
+	"net/url"
+)
 
 type Title struct {
 	Name      string   `json:"name"`
 	Reference *url.URL `json:"reference"`
 }
+
+// titleJSON is the JSON representation of a Title, where the
+// reference is serialized in its string form.
+type titleJSON struct {
+	Name      string  `json:"name"`
+	Reference *string `json:"reference"`
+}
+
+// MarshalJSON serializes the Title with its reference as a string,
+// or as null when the title has no reference.
+func (t Title) MarshalJSON() ([]byte, error) {
+	var reference *string
+
+	if t.Reference != nil {
+		s := t.Reference.String()
+		reference = &s
+	}
+
+	return json.Marshal(titleJSON{Name: t.Name, Reference: reference})
+}
+
+// UnmarshalJSON deserializes the Title, parsing its reference with
+// url.Parse. A null or empty reference yields a nil Reference.
+func (t *Title) UnmarshalJSON(data []byte) error {
+	var raw titleJSON
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	t.Name = raw.Name
+	t.Reference = nil
+
+	if raw.Reference == nil || *raw.Reference == "" {
+		return nil
+	}
+
+	reference, err := url.Parse(*raw.Reference)
+
+	if err != nil {
+		return err
+	}
+
+	t.Reference = reference
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package model_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTitleJSON tests that a title survives a JSON round-trip
+// with its reference serialized as a string.
+func TestTitleJSON(t *testing.T) {
+	reference, err := url.Parse("https://github.com/glenjamin/node-fib")
+
+	assert.Nil(t, err)
+
+	tests := []struct {
+		Title    model.Title
+		JSON     string
+		Testname string
+	}{
+		{
+			Title: model.Title{
+				Name:      "Node-fib: Fast non-blocking fibonacci server",
+				Reference: reference,
+			},
+			JSON:     `{"name":"Node-fib: Fast non-blocking fibonacci server","reference":"https://github.com/glenjamin/node-fib"}`,
+			Testname: "TestReference",
+		},
+		{
+			Title: model.Title{
+				Name: "Ask HN: How do you keep up with your reading list?",
+			},
+			JSON:     `{"name":"Ask HN: How do you keep up with your reading list?","reference":null}`,
+			Testname: "TestNilReference",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			encoded, err := json.Marshal(test.Title)
+
+			assert.Nil(t, err)
+
+			assert.JSONEq(t, test.JSON, string(encoded))
+
+			var decoded model.Title
+
+			err = json.Unmarshal(encoded, &decoded)
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Title, decoded)
+		})
+	}
+}
+
+// TestTitleJSONEmptyReference tests that an empty reference
+// string decodes to a nil reference.
+func TestTitleJSONEmptyReference(t *testing.T) {
+	var decoded model.Title
+
+	err := json.Unmarshal([]byte(`{"name":"Ask HN","reference":""}`), &decoded)
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, decoded.Reference)
+}
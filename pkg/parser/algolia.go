@@ -0,0 +1,151 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+)
+
+// algoliaItem is the shape of an item returned by the HN Algolia
+// API at https://hn.algolia.com/api/v1/items/{id}.
+type algoliaItem struct {
+	ID         int           `json:"id"`
+	CreatedAtI int64         `json:"created_at_i"`
+	Type       string        `json:"type"`
+	Author     string        `json:"author"`
+	Title      string        `json:"title"`
+	URL        string        `json:"url"`
+	Text       string        `json:"text"`
+	Points     *int          `json:"points"`
+	ParentID   *int          `json:"parent_id"`
+	Children   []algoliaItem `json:"children"`
+	Options    []algoliaItem `json:"options"`
+}
+
+// ParseAlgoliaItem decodes an item from the HN Algolia API JSON shape (as served by
+// https://hn.algolia.com/api/v1/items/{id}) from the provided io.Reader into a
+// model.Item, so that it can be used interchangeably with the output of ParseHTML.
+// The nested children are flattened into the comments in display order. As with
+// ParseHTML, top-level comments have a nil ParentID. Dates are taken from the
+// "created_at_i" Unix timestamp. Returns an error if the JSON cannot be decoded or
+// the URL cannot be parsed.
+func ParseAlgoliaItem(r io.Reader) (*model.Item, error) {
+	var raw algoliaItem
+
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	item := model.Item{
+		Title:     model.Title{Name: raw.Title},
+		Author:    raw.Author,
+		Date:      time.Unix(raw.CreatedAtI, 0).UTC(),
+		Timestamp: raw.CreatedAtI,
+		ID:        raw.ID,
+		Text:      raw.Text,
+		IsJob:     raw.Type == "job",
+	}
+
+	if raw.URL != "" {
+		reference, err := url.Parse(raw.URL)
+
+		if err != nil {
+			return nil, err
+		}
+
+		item.Title.Reference = reference
+		item.Domain = strings.TrimPrefix(reference.Hostname(), "www.")
+	}
+
+	if raw.Points != nil {
+		item.Points = *raw.Points
+	}
+
+	item.Found = model.FieldsFound{
+		Title:  raw.Title != "",
+		ID:     raw.ID != 0,
+		Score:  raw.Points != nil,
+		Date:   raw.CreatedAtI != 0,
+		Author: raw.Author != "",
+	}
+
+	for _, option := range raw.Options {
+		if item.Poll == nil {
+			item.Poll = &model.Poll{}
+		}
+
+		pollOption := model.PollOption{Text: option.Text}
+
+		if option.Points != nil {
+			pollOption.Points = *option.Points
+		}
+
+		item.Poll.Options = append(item.Poll.Options, pollOption)
+	}
+
+	item.Comments = flattenAlgoliaChildren(raw.Children, raw.ID, 0, nil)
+	item.CommentCount = len(item.Comments)
+
+	return &item, nil
+}
+
+// flattenAlgoliaChildren recursively flattens the provided Algolia children at the
+// provided depth into comments in display order, appending them to comments. The
+// ParentID of children replying directly to the story is left nil.
+func flattenAlgoliaChildren(children []algoliaItem, storyID int, depth int, comments []model.Comment) []model.Comment {
+	for _, child := range children {
+		comment := model.Comment{
+			Author:    child.Author,
+			Content:   child.Text,
+			Date:      time.Unix(child.CreatedAtI, 0).UTC(),
+			Timestamp: child.CreatedAtI,
+			ID:        child.ID,
+			Depth:     depth,
+			Points:    child.Points,
+		}
+
+		if child.ParentID != nil && *child.ParentID != storyID {
+			parentID := *child.ParentID
+			comment.ParentID = &parentID
+		}
+
+		comments = append(comments, comment)
+
+		comments = flattenAlgoliaChildren(child.Children, storyID, depth+1, comments)
+	}
+
+	return comments
+}
@@ -0,0 +1,94 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+)
+
+// ParseAll parses the provided HTML documents concurrently with a pool of at most
+// the specified number of workers. ParseAll uses a Parser with the default options.
+func ParseAll(ctx context.Context, docs []io.Reader, workers int) ([]*model.Item, []error) {
+	return defaultParser.ParseAll(ctx, docs, workers)
+}
+
+// ParseAll parses the provided HTML documents concurrently with a pool of at most
+// the specified number of workers, which is raised to one if lower. The returned
+// slices preserve the order of the documents: the item and error at index i are
+// those of the document at index i, as returned by ParseHTMLWithContext. Once the
+// context is done, the documents that were not yet handed to a worker are not
+// parsed, and their error is the context's error.
+func (p *Parser) ParseAll(ctx context.Context, docs []io.Reader, workers int) ([]*model.Item, []error) {
+	items := make([]*model.Item, len(docs))
+	errs := make([]error, len(docs))
+
+	workers = max(1, min(workers, len(docs)))
+
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// each worker writes to distinct
+			// indexes, so no lock is needed
+			for i := range indexes {
+				items[i], errs[i] = p.ParseHTMLWithContext(ctx, docs[i])
+			}
+		}()
+	}
+
+dispatch:
+	for i := range docs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			for j := i; j < len(docs); j++ {
+				errs[j] = ctx.Err()
+			}
+
+			break dispatch
+		}
+	}
+
+	close(indexes)
+
+	wg.Wait()
+
+	return items, errs
+}
@@ -0,0 +1,129 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseAll tests that documents parsed concurrently yield
+// the same items and errors as when parsed one by one, in the
+// order of the documents.
+func TestParseAll(t *testing.T) {
+	files := []string{
+		"sample1.html",
+		"sample_ask.html",
+		"sample_notfound.html",
+		"sample_job.html",
+		"sample_poll.html",
+		"sample_ratelimited.html",
+		"sample_threads.html",
+	}
+
+	var samples [][]byte
+
+	for _, file := range files {
+		sample, err := os.ReadFile(filepath.Join("testdata", file))
+
+		assert.Nil(t, err)
+
+		samples = append(samples, sample)
+	}
+
+	tests := []struct {
+		Workers  int
+		Testname string
+	}{
+		{Workers: 0, Testname: "TestNoWorkers"},
+		{Workers: 1, Testname: "TestOneWorker"},
+		{Workers: 3, Testname: "TestSomeWorkers"},
+		{Workers: 100, Testname: "TestMoreWorkersThanDocuments"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			var docs []io.Reader
+
+			for _, sample := range samples {
+				docs = append(docs, bytes.NewReader(sample))
+			}
+
+			items, errs := parser.ParseAll(context.Background(), docs, test.Workers)
+
+			if !assert.Len(t, items, len(samples)) || !assert.Len(t, errs, len(samples)) {
+				return
+			}
+
+			for i, sample := range samples {
+				expected, expectedErr := parser.ParseHTML(bytes.NewReader(sample))
+
+				assert.Equal(t, expected, items[i], files[i])
+
+				assert.Equal(t, expectedErr, errs[i], files[i])
+			}
+		})
+	}
+}
+
+// TestParseAllCanceled tests that no document is parsed once
+// the context is done, and that each reports the context's error.
+func TestParseAllCanceled(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	docs := []io.Reader{bytes.NewReader(sample), bytes.NewReader(sample), bytes.NewReader(sample)}
+
+	items, errs := parser.ParseAll(ctx, docs, 2)
+
+	for i := range docs {
+		assert.Nil(t, items[i])
+
+		assert.ErrorIs(t, errs[i], context.Canceled)
+	}
+
+	items, errs = parser.ParseAll(context.Background(), nil, 4)
+
+	assert.Empty(t, items)
+
+	assert.Empty(t, errs)
+}
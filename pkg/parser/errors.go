@@ -0,0 +1,154 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrInvalidItemURL is reported when a URL does not point
+// to an HN item.
+var ErrInvalidItemURL = errors.New("parser: invalid item URL")
+
+// ErrRateLimited is reported when HN served its rate-limit
+// page in place of the requested page.
+var ErrRateLimited = errors.New("parser: rate limited by HN")
+
+// ErrValidationRequired is reported when HN served its "Validation
+// required" interstitial in place of the requested page, which is
+// cleared by acquiring a cookie rather than by backing off.
+var ErrValidationRequired = errors.New("parser: validation required by HN")
+
+// ErrItemNotFound is reported when HN served its "No such
+// item." page in place of the requested item.
+var ErrItemNotFound = errors.New("parser: no such item")
+
+// ErrUserNotFound is reported when a page holds no user
+// profile, as is the case for the "No such user." page.
+var ErrUserNotFound = errors.New("parser: no such user")
+
+// ErrCommentNotFound is reported when a CommentIndex
+// holds no comment with the requested ID.
+var ErrCommentNotFound = errors.New("parser: no such comment")
+
+// ErrMissingFields is reported by a strict Parser when
+// required fields are absent from the parsed page.
+var ErrMissingFields = errors.New("parser: missing required fields")
+
+// ErrUnsupportedEncoding is reported when the body of an HTTP
+// response has a Content-Encoding that cannot be decoded.
+var ErrUnsupportedEncoding = errors.New("parser: unsupported content encoding")
+
+// ErrDocumentTooLarge is reported when a document exceeds
+// the maximum size set by WithMaxBytes.
+var ErrDocumentTooLarge = errors.New("parser: document too large")
+
+// errMalformed is reported when a raw value does not have
+// the expected shape.
+var errMalformed = errors.New("malformed value")
+
+// ParseError describes a failure to parse the raw value of
+// a field extracted from an HN page.
+type ParseError struct {
+	// Field is the name of the field that failed to parse.
+	Field string
+
+	// Raw is the raw value that failed to parse.
+	Raw string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns a description of the parse error, including
+// the field, the raw value, and the underlying error.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parser: parsing %s %q: %v", e.Field, e.Raw, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError describes a response that was received with
+// a status other than 200 OK.
+type StatusError struct {
+	// URL is the URL that was requested.
+	URL string
+
+	// StatusCode is the status code of the response.
+	StatusCode int
+}
+
+// Error returns a description of the status error, including
+// the requested URL and the received status.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("parser: fetching %s: unexpected status %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// PanicError describes a panic that was recovered from
+// while parsing an HN page.
+type PanicError struct {
+	// Value is the value the panic was called with.
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// Error returns a description of the panic, including
+// the stack trace of the panicking goroutine.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("parser: recovered from panic: %v\n%s", e.Value, e.Stack)
+}
+
+// CommentError describes a failure to extract a comment,
+// identifying the comment by its ID.
+type CommentError struct {
+	// ID is the ID of the comment that failed to extract.
+	ID int
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns a description of the comment error, including
+// the ID of the comment and the underlying error.
+func (e *CommentError) Error() string {
+	return fmt.Sprintf("parser: comment %d: %v", e.ID, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CommentError) Unwrap() error {
+	return e.Err
+}
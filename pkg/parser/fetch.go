@@ -0,0 +1,241 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+)
+
+// itemHost specifies the host that serves HN items.
+const itemHost = "news.ycombinator.com"
+
+// itemPath specifies the path that serves HN items.
+const itemPath = "/item"
+
+// ParseURL fetches the HN item at the provided URL with a GET request bound
+// to the provided context, and parses the response body with ParseHTMLWithContext,
+// decompressing it as ParseResponse does. The provided client is used to perform
+// the request, or http.DefaultClient when it is nil. Returns ErrInvalidItemURL if
+// the URL is not an HN item URL, and a *StatusError if the response status is not
+// 200 OK. ParseURL uses a Parser with the default options, including the default
+// timeout of 30 seconds.
+func ParseURL(ctx context.Context, client *http.Client, itemURL string) (*model.Item, error) {
+	return defaultParser.ParseURL(ctx, client, itemURL)
+}
+
+// ParseURL fetches the HN item at the provided URL with a GET request bound
+// to the provided context, and parses the response body with ParseHTMLWithContext,
+// decompressing it as ParseResponse does. The request carries the User-Agent and
+// Referer headers configured on the Parser, and is bound to the timeout configured
+// on the Parser unless the context carries a deadline of its own. The provided
+// client is used to perform the request, or the client configured on the Parser,
+// or http.DefaultClient when neither is set. Transient failures are retried as
+// configured by WithRetries. Returns ErrInvalidItemURL if the URL is not an HN
+// item URL, and a *StatusError if the response status is not 200 OK.
+func (p *Parser) ParseURL(ctx context.Context, client *http.Client, itemURL string) (*model.Item, error) {
+	if err := validateItemURL(itemURL); err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		client = p.client
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	backoff := p.backoff
+
+	for attempt := 0; ; attempt++ {
+		item, err := p.fetchItem(ctx, client, itemURL)
+
+		if err == nil || attempt >= p.retries || !isTransient(err) {
+			return item, err
+		}
+
+		// a backoff outliving the deadline would only delay the failure
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return nil, err
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+// fetchItem performs a single attempt of ParseURL, fetching the HN item at
+// the provided URL with the provided client and parsing the response body.
+func (p *Parser) fetchItem(ctx context.Context, client *http.Client, itemURL string) (*model.Item, error) {
+	// a deadline set by the caller takes precedence
+	if _, ok := ctx.Deadline(); !ok && p.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, itemURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", p.userAgent)
+
+	if p.referer != "" {
+		req.Header.Set("Referer", p.referer)
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: itemURL, StatusCode: resp.StatusCode}
+	}
+
+	body, err := decodeBody(resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer body.Close()
+
+	return p.ParseHTMLWithContext(ctx, body)
+}
+
+// isTransient reports whether the provided error of a fetch is worth retrying,
+// namely a 5xx status or the rate-limit page of HN.
+func isTransient(err error) bool {
+	var statusErr *StatusError
+
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return errors.Is(err, ErrRateLimited)
+}
+
+// ParseResponse parses the body of the provided HTTP response with ParseHTML,
+// transparently decompressing it when its Content-Encoding is gzip, as is the
+// case when the request set its own Accept-Encoding header. The caller remains
+// responsible for closing the body of the response. Returns an error wrapping
+// ErrUnsupportedEncoding for any other encoding, and an error if the body cannot
+// be decompressed or parsed. ParseResponse uses a Parser with the default
+// options.
+func ParseResponse(resp *http.Response) (*model.Item, error) {
+	return defaultParser.ParseResponse(resp)
+}
+
+// ParseResponse parses the body of the provided HTTP response with ParseHTML,
+// transparently decompressing it when its Content-Encoding is gzip, as is the
+// case when the request set its own Accept-Encoding header. The caller remains
+// responsible for closing the body of the response. Returns an error wrapping
+// ErrUnsupportedEncoding for any other encoding, and an error if the body cannot
+// be decompressed or parsed.
+func (p *Parser) ParseResponse(resp *http.Response) (*model.Item, error) {
+	body, err := decodeBody(resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer body.Close()
+
+	return p.ParseHTML(body)
+}
+
+// decodeBody returns a reader over the decoded body of the provided HTTP
+// response, according to its Content-Encoding. Closing the returned reader
+// does not close the body of the response. Returns an error wrapping
+// ErrUnsupportedEncoding if the encoding is not supported, or an error if the gzip header of the body is invalid.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "", "identity":
+		return io.NopCloser(resp.Body), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// validateItemURL checks that the provided URL points to an HN item, e.g.
+// "https://news.ycombinator.com/item?id=3067403". Returns an error wrapping
+// ErrInvalidItemURL otherwise.
+func validateItemURL(itemURL string) error {
+	parsed, err := url.Parse(itemURL)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidItemURL, err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrInvalidItemURL, parsed.Scheme)
+	}
+
+	if parsed.Host != itemHost || parsed.Path != itemPath {
+		return fmt.Errorf("%w: %q is not an item page", ErrInvalidItemURL, itemURL)
+	}
+
+	if _, err := strconv.Atoi(parsed.Query().Get("id")); err != nil {
+		return fmt.Errorf("%w: %q has no numeric id", ErrInvalidItemURL, itemURL)
+	}
+
+	return nil
+}
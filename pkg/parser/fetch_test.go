@@ -0,0 +1,477 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// rewriteTransport sends every request to the wrapped
+// test server, regardless of the requested host.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+// RoundTrip rewrites the request to point at the target
+// and performs it with the default transport.
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestClient returns a client whose requests are all
+// served by the provided handler.
+func newTestClient(t *testing.T, handler http.Handler) *http.Client {
+	server := httptest.NewServer(handler)
+
+	t.Cleanup(server.Close)
+
+	target, err := url.Parse(server.URL)
+
+	assert.Nil(t, err)
+
+	return &http.Client{Transport: rewriteTransport{target: target}}
+}
+
+// TestParseURL tests that an item is fetched and parsed
+// in a single call.
+func TestParseURL(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/item", r.URL.Path)
+
+		assert.Equal(t, "3067403", r.URL.Query().Get("id"))
+
+		http.ServeFile(w, r, filepath.Join("testdata", "sample1.html"))
+	}))
+
+	parsed, err := parser.ParseURL(context.Background(), client, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3067403, parsed.ID)
+
+	assert.Equal(t, 118, len(parsed.Comments))
+}
+
+// TestParseURLHeaders tests that requests carry the default
+// user agent, or the configured user agent and referer.
+func TestParseURLHeaders(t *testing.T) {
+	var userAgent, referer string
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		referer = r.Referer()
+
+		http.ServeFile(w, r, filepath.Join("testdata", "sample1.html"))
+	}))
+
+	_, err := parser.ParseURL(context.Background(), client, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.Nil(t, err)
+
+	assert.True(t, strings.HasPrefix(userAgent, "hn-item-parser/"))
+
+	assert.Equal(t, "", referer)
+
+	p := parser.New(parser.WithUserAgent("archiver/1.0"), parser.WithReferer("https://news.ycombinator.com/news"))
+
+	_, err = p.ParseURL(context.Background(), client, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "archiver/1.0", userAgent)
+
+	assert.Equal(t, "https://news.ycombinator.com/news", referer)
+}
+
+// TestParseURLStatus tests that a status other than
+// 200 OK is reported as a parser.StatusError.
+func TestParseURLStatus(t *testing.T) {
+	client := newTestClient(t, http.NotFoundHandler())
+
+	_, err := parser.ParseURL(context.Background(), client, "https://news.ycombinator.com/item?id=3067403")
+
+	var statusErr *parser.StatusError
+
+	assert.True(t, errors.As(err, &statusErr))
+
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+}
+
+// TestWithTimeout tests that a request outliving the timeout of the
+// Parser is abandoned, unless the context carries its own deadline.
+func TestWithTimeout(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+			http.ServeFile(w, r, filepath.Join("testdata", "sample1.html"))
+		case <-r.Context().Done():
+		}
+	}))
+
+	p := parser.New(parser.WithTimeout(20 * time.Millisecond))
+
+	_, err := p.ParseURL(context.Background(), client, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the deadline of the context takes precedence
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	parsed, err := p.ParseURL(ctx, client, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3067403, parsed.ID)
+
+	// a timeout of zero disables it
+	parsed, err = parser.New(parser.WithTimeout(0)).ParseURL(context.Background(), client, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3067403, parsed.ID)
+}
+
+// TestWithRetries tests that transient failures are retried with a
+// doubling backoff, and that other failures are reported at once.
+func TestWithRetries(t *testing.T) {
+	tests := []struct {
+		Responses []string
+		Requests  int
+		Err       error
+		Status    int
+		Testname  string
+	}{
+		{
+			Responses: []string{"503", "ratelimited", "sample1.html"},
+			Requests:  3,
+			Testname:  "TestRecovered",
+		},
+		{
+			Responses: []string{"500", "502", "503", "504"},
+			Requests:  4,
+			Status:    http.StatusGatewayTimeout,
+			Testname:  "TestExhausted",
+		},
+		{
+			Responses: []string{"ratelimited", "ratelimited", "ratelimited", "ratelimited"},
+			Requests:  4,
+			Err:       parser.ErrRateLimited,
+			Testname:  "TestRateLimited",
+		},
+		{
+			Responses: []string{"404", "sample1.html"},
+			Requests:  1,
+			Status:    http.StatusNotFound,
+			Testname:  "TestNotFoundStatus",
+		},
+		{
+			Responses: []string{"validation", "sample1.html"},
+			Requests:  1,
+			Err:       parser.ErrValidationRequired,
+			Testname:  "TestValidationRequired",
+		},
+		{
+			Responses: []string{"notfound", "sample1.html"},
+			Requests:  1,
+			Err:       parser.ErrItemNotFound,
+			Testname:  "TestNoSuchItem",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			var requests int
+
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response := test.Responses[requests]
+
+				requests++
+
+				switch response {
+				case "ratelimited", "validation", "notfound":
+					http.ServeFile(w, r, filepath.Join("testdata", "sample_"+response+".html"))
+				case "sample1.html":
+					http.ServeFile(w, r, filepath.Join("testdata", response))
+				default:
+					status, err := strconv.Atoi(response)
+
+					assert.Nil(t, err)
+
+					w.WriteHeader(status)
+				}
+			}))
+
+			p := parser.New(parser.WithRetries(3, time.Millisecond))
+
+			parsed, err := p.ParseURL(context.Background(), client, "https://news.ycombinator.com/item?id=3067403")
+
+			assert.Equal(t, test.Requests, requests)
+
+			switch {
+			case test.Err != nil:
+				assert.ErrorIs(t, err, test.Err)
+			case test.Status != 0:
+				var statusErr *parser.StatusError
+
+				assert.True(t, errors.As(err, &statusErr))
+
+				assert.Equal(t, test.Status, statusErr.StatusCode)
+			default:
+				assert.Nil(t, err)
+
+				assert.Equal(t, 3067403, parsed.ID)
+			}
+		})
+	}
+}
+
+// TestWithRetriesDeadline tests that no retry is attempted when
+// its backoff would outlive the deadline of the context.
+func TestWithRetriesDeadline(t *testing.T) {
+	var requests int
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := parser.New(parser.WithRetries(5, time.Minute)).ParseURL(ctx, client, "https://news.ycombinator.com/item?id=3067403")
+
+	var statusErr *parser.StatusError
+
+	assert.True(t, errors.As(err, &statusErr))
+
+	assert.Equal(t, 1, requests)
+
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// TestWithRetriesCancelled tests that a retry interrupted by the
+// cancellation of the context reports the cancellation along with
+// the error of the last attempt.
+func TestWithRetriesCancelled(t *testing.T) {
+	var requests int
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		// cancel while the retry is backing off
+		time.AfterFunc(10*time.Millisecond, cancel)
+	}))
+
+	start := time.Now()
+
+	_, err := parser.New(parser.WithRetries(5, time.Minute)).ParseURL(ctx, client, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var statusErr *parser.StatusError
+
+	if assert.True(t, errors.As(err, &statusErr)) {
+		assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	}
+
+	assert.Equal(t, 1, requests)
+
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// TestWithHTTPClient tests that the client of the Parser performs
+// the requests, unless a client is provided to the call.
+func TestWithHTTPClient(t *testing.T) {
+	var served int
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+
+		http.ServeFile(w, r, filepath.Join("testdata", "sample1.html"))
+	}))
+
+	p := parser.New(parser.WithHTTPClient(client))
+
+	parsed, err := p.ParseURL(context.Background(), nil, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3067403, parsed.ID)
+
+	assert.Equal(t, 1, served)
+
+	// a client provided to the call takes precedence
+	other := newTestClient(t, http.NotFoundHandler())
+
+	_, err = p.ParseURL(context.Background(), other, "https://news.ycombinator.com/item?id=3067403")
+
+	assert.NotNil(t, err)
+
+	assert.Equal(t, 1, served)
+}
+
+// TestParseURLInvalid tests that URLs which do not point
+// to an HN item are rejected before any request is made.
+func TestParseURLInvalid(t *testing.T) {
+	tests := []struct {
+		URL      string
+		Testname string
+	}{
+		{
+			URL:      "https://example.com/item?id=3067403",
+			Testname: "TestWrongHost",
+		},
+		{
+			URL:      "https://news.ycombinator.com/user?id=dchest",
+			Testname: "TestWrongPath",
+		},
+		{
+			URL:      "https://news.ycombinator.com/item?id=abc",
+			Testname: "TestNonNumericID",
+		},
+		{
+			URL:      "ftp://news.ycombinator.com/item?id=3067403",
+			Testname: "TestWrongScheme",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			_, err := parser.ParseURL(context.Background(), nil, test.URL)
+
+			assert.ErrorIs(t, err, parser.ErrInvalidItemURL)
+		})
+	}
+}
+
+// TestParseResponse tests that the body of a response is
+// decompressed according to its Content-Encoding.
+func TestParseResponse(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	var compressed bytes.Buffer
+
+	writer := gzip.NewWriter(&compressed)
+
+	_, err = writer.Write(sample)
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, writer.Close())
+
+	tests := []struct {
+		Encoding string
+		Body     []byte
+		Testname string
+	}{
+		{
+			Encoding: "",
+			Body:     sample,
+			Testname: "TestIdentity",
+		},
+		{
+			Encoding: "gzip",
+			Body:     compressed.Bytes(),
+			Testname: "TestGzip",
+		},
+		{
+			Encoding: "x-gzip",
+			Body:     compressed.Bytes(),
+			Testname: "TestXGzip",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(bytes.NewReader(test.Body)),
+			}
+
+			if test.Encoding != "" {
+				resp.Header.Set("Content-Encoding", test.Encoding)
+			}
+
+			parsed, err := parser.ParseResponse(resp)
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, 3067403, parsed.ID)
+
+			assert.Equal(t, 118, len(parsed.Comments))
+		})
+	}
+}
+
+// TestParseResponseUnsupportedEncoding tests that an encoding
+// that cannot be decoded is reported.
+func TestParseResponseUnsupportedEncoding(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"br"}},
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+	}
+
+	_, err := parser.ParseResponse(resp)
+
+	assert.ErrorIs(t, err, parser.ErrUnsupportedEncoding)
+}
@@ -0,0 +1,101 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"io"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ParseCommentFragment parses an HTML fragment holding a bare sequence of comment
+// rows from the provided io.Reader, and returns its comments in document order.
+// ParseCommentFragment uses a Parser with the default options.
+func ParseCommentFragment(doc io.Reader) ([]model.Comment, error) {
+	return defaultParser.ParseCommentFragment(doc)
+}
+
+// ParseCommentFragment parses an HTML fragment holding a bare sequence of comment
+// rows from the provided io.Reader, such as the expanded subtree that HN returns
+// when a collapsed comment is expanded, and returns its comments in document order.
+// Unlike the rows of a page, the rows of a fragment are not wrapped in a "comment-tree"
+// table, so the fragment is parsed within a table body, where such rows belong.
+// Returns an error if the fragment cannot be parsed, or if any of the comments
+// cannot be extracted.
+func (p *Parser) ParseCommentFragment(doc io.Reader) (comments []model.Comment, err error) {
+	defer recoverPanic(&err)
+
+	var nodes []*html.Node
+
+	err = p.readLimited(doc, func(r io.Reader) (err error) {
+		nodes, err = html.ParseFragment(r, &html.Node{Type: html.ElementNode, Data: "tbody", DataAtom: atom.Tbody})
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// wrap the rows in a tree of their own, so that
+	// they are visited just like those of a page
+	treeNode := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "table",
+		DataAtom: atom.Table,
+		Attr:     []html.Attribute{{Key: "class", Val: p.classes.CommentTree}},
+	}
+
+	for _, node := range nodes {
+		treeNode.AppendChild(node)
+	}
+
+	shown := make(map[int]bool)
+
+	_, err = p.visitComments(treeNode, func(comment *model.Comment, replyCountShown bool) error {
+		comments = append(comments, *comment)
+
+		if replyCountShown {
+			shown[comment.ID] = true
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	countReplies(comments, shown)
+
+	return comments, nil
+}
@@ -0,0 +1,90 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseCommentFragment tests that the bare comment rows of a
+// fragment yield the same comments as they do within their page.
+func TestParseCommentFragment(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	full, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	fragment, err := os.ReadFile(filepath.Join("testdata", "sample_fragment.html"))
+
+	assert.Nil(t, err)
+
+	comments, err := parser.ParseCommentFragment(bytes.NewReader(fragment))
+
+	assert.Nil(t, err)
+
+	if assert.Len(t, comments, 4) {
+		expected := slices.Clone(full.Comments[1:5])
+
+		// only the replies within the fragment are counted
+		for i, count := range []int{1, 1, 1, 0} {
+			expected[i].ReplyCount = count
+		}
+
+		assert.Equal(t, expected, comments)
+
+		for i, comment := range comments {
+			assert.Equal(t, i, comment.Depth)
+		}
+	}
+
+	// the rows of a full page are found within their table
+	comments, err = parser.ParseCommentFragment(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, len(full.Comments), len(comments))
+
+	comments, err = parser.ParseCommentFragment(strings.NewReader(""))
+
+	assert.Nil(t, err)
+
+	assert.Empty(t, comments)
+}
@@ -0,0 +1,116 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"io"
+	"strconv"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ExtractID extracts the ID of the item of the page held by the provided
+// io.Reader, without parsing the rest of the page. ExtractID uses a Parser
+// with the default options.
+func ExtractID(doc io.Reader) (int, error) {
+	return defaultParser.ExtractID(doc)
+}
+
+// ExtractID extracts the ID of the item of the page held by the provided io.Reader,
+// such as to deduplicate pages before deciding whether to parse them in full. Rather
+// than building the node tree of the whole page, it tokenizes the page and stops at
+// the first item row, which precedes the comments, so that the comments are neither
+// read nor parsed. On the permalink page of a comment, the ID is that of the comment.
+// Returns a *ParseError if the ID cannot be parsed, ErrItemNotFound if the page holds
+// no item row, as is the case for the "No such item." page, or an error if the page
+// cannot be read.
+func (p *Parser) ExtractID(doc io.Reader) (id int, err error) {
+	defer recoverPanic(&err)
+
+	err = p.readLimited(doc, func(r io.Reader) error {
+		id, err = p.scanID(r)
+
+		return err
+	})
+
+	return id, err
+}
+
+// scanID tokenizes the document read from the provided io.Reader up to the first
+// item row, and parses its ID. Returns ErrItemNotFound if the document ends before
+// any item row, or an error if the document cannot be read or the ID parsed.
+func (p *Parser) scanID(r io.Reader) (int, error) {
+	tokenizer := html.NewTokenizer(r)
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return 0, err
+			}
+
+			return 0, ErrItemNotFound
+		case html.StartTagToken:
+			name, hasAttr := tokenizer.TagName()
+
+			if atom.Lookup(name) != atom.Tr || !hasAttr {
+				continue
+			}
+
+			// wrap the attributes in a node of their own,
+			// so that the row is matched just like those
+			// of a parsed page
+			row := &html.Node{Type: html.ElementNode, Data: "tr", DataAtom: atom.Tr}
+
+			for more := true; more; {
+				var key, val []byte
+
+				key, val, more = tokenizer.TagAttr()
+
+				row.Attr = append(row.Attr, html.Attribute{Key: string(key), Val: string(val)})
+			}
+
+			if !p.isItemRow(row) {
+				continue
+			}
+
+			idString := getAttr(row, "id")
+
+			id, err := strconv.Atoi(idString)
+
+			if err != nil {
+				return 0, &ParseError{Field: "id", Raw: idString, Err: err}
+			}
+
+			return id, nil
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"golang.org/x/net/html"
+)
+
+// CommentIndex locates the comments of a parsed document by their ID, so that
+// each comment is only extracted when it is requested. It retains the parsed
+// document, and is safe for concurrent use.
+type CommentIndex struct {
+	// parser is the Parser that extracts
+	// the comments on request.
+	parser *Parser
+
+	// rows are the rows of the comments,
+	// keyed by the ID of their comment.
+	rows map[int]*html.Node
+
+	// ids are the IDs of the comments, in
+	// the order in which they are displayed.
+	ids []int
+
+	// replies are the numbers of direct
+	// replies to the comments, keyed by
+	// the ID of their parent.
+	replies map[int]int
+
+	// roots is the number of comments
+	// without a parent link.
+	roots int
+
+	// author is the author of the item, whose
+	// comments are marked as the original
+	// poster's.
+	author string
+
+	// focused is the comment that a permalink
+	// page focuses on, if any, which leads the
+	// indexed comments.
+	focused *model.Comment
+}
+
+// ParseIndex parses an HTML document from the provided io.Reader, returning the
+// item metadata along with an index of its comments. ParseIndex uses a Parser with
+// the default options.
+func ParseIndex(doc io.Reader) (*model.Item, *CommentIndex, error) {
+	return defaultParser.ParseIndex(doc)
+}
+
+// ParseIndex parses an HTML document from the provided io.Reader, returning the
+// item metadata (title, author, etc.) without its comments, along with an index
+// from which each comment can be extracted on demand. This avoids allocating every
+// comment of a large thread when only a few of them are needed. The fields that
+// are derived from the comments, such as the participants, are left empty.
+func (p *Parser) ParseIndex(doc io.Reader) (item *model.Item, index *CommentIndex, err error) {
+	defer recoverPanic(&err)
+
+	node, err := p.parseDocument(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := p.checkErrorPage(node); err != nil {
+		return nil, nil, err
+	}
+
+	item = &model.Item{}
+
+	var visited int
+
+	metadataParser := *p
+	metadataParser.skipComments = true
+
+	if err := metadataParser.walkNode(context.Background(), node, item, NopVisitor{}, &visited); err != nil {
+		return item, nil, err
+	}
+
+	inferKind(item)
+
+	focused, err := metadataParser.extractFocusedComment(node, item)
+
+	if err != nil {
+		return item, nil, err
+	}
+
+	if err := metadataParser.extractOpenGraph(node, item); err != nil {
+		return item, nil, err
+	}
+
+	extractPageTitle(node, item)
+
+	index, err = p.indexComments(node)
+
+	if err != nil {
+		return item, nil, err
+	}
+
+	index.author = item.Author
+
+	if focused != nil {
+		// the comments without a parent link
+		// are the replies to the focused one
+		if metadataParser.replyCountNode(metadataParser.focusedCommentRow(node)) == nil {
+			focused.ReplyCount = index.roots
+		}
+
+		index.focused = focused
+		index.ids = append([]int{focused.ID}, index.ids...)
+	}
+
+	if p.strict {
+		return item, index, checkRequiredFields(item)
+	}
+
+	return item, index, nil
+}
+
+// indexComments builds the index of the comment rows within the "comment-tree"
+// of the provided document, counting the direct replies to each comment from
+// the parent links of the rows. Returns an error if the ID of a comment cannot
+// be parsed.
+func (p *Parser) indexComments(node *html.Node) (*CommentIndex, error) {
+	index := &CommentIndex{parser: p, rows: make(map[int]*html.Node), replies: make(map[int]int)}
+
+	treeNode := getChildRefByClass(node, p.classes.CommentTree)
+
+	if treeNode == nil {
+		return index, nil
+	}
+
+	var err error
+
+	traverseNode(treeNode, func(n *html.Node) {
+		if err != nil || !p.isCommentRow(n) {
+			return
+		}
+
+		var comment model.Comment
+
+		if err = p.extractCommentID(n, &comment); err != nil {
+			return
+		}
+
+		if _, ok := index.rows[comment.ID]; ok {
+			return
+		}
+
+		index.rows[comment.ID] = n
+		index.ids = append(index.ids, comment.ID)
+
+		if err = extractParentID(n, &comment); err != nil {
+			return
+		}
+
+		if comment.ParentID != nil {
+			index.replies[*comment.ParentID]++
+		} else {
+			index.roots++
+		}
+	})
+
+	return index, err
+}
+
+// Len returns the number of comments in the index.
+func (index *CommentIndex) Len() int {
+	return len(index.ids)
+}
+
+// IDs returns the IDs of the comments in the index, in the order in which they
+// are displayed.
+func (index *CommentIndex) IDs() []int {
+	return append([]int(nil), index.ids...)
+}
+
+// Comment extracts the comment with the provided ID from the document. Returns an
+// error wrapping ErrCommentNotFound if the document holds no such comment, or an
+// error if the comment cannot be extracted.
+func (index *CommentIndex) Comment(id int) (comment *model.Comment, err error) {
+	defer recoverPanic(&err)
+
+	if index.focused != nil && index.focused.ID == id {
+		focused := *index.focused
+
+		markOP(&focused, index.author)
+
+		return &focused, nil
+	}
+
+	row, ok := index.rows[id]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrCommentNotFound, id)
+	}
+
+	comment, err = index.parser.extractComment(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the row lacks the body of a comment
+	if comment == nil {
+		return nil, fmt.Errorf("%w: %d", ErrCommentNotFound, id)
+	}
+
+	if index.focused != nil {
+		nestReply(comment, index.focused)
+	}
+
+	// the markup of the comment may show its replies
+	if index.parser.replyCountNode(row) == nil {
+		comment.ReplyCount = index.replies[comment.ID]
+	}
+
+	markOP(comment, index.author)
+
+	return comment, nil
+}
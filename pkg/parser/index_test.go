@@ -0,0 +1,138 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseIndex tests that the comments extracted on demand
+// from the index match those of a full parse, and that the
+// metadata of the item is unaffected.
+func TestParseIndex(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	full, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	item, index, err := parser.ParseIndex(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	var ids []int
+
+	for _, comment := range full.Comments {
+		ids = append(ids, comment.ID)
+	}
+
+	assert.Equal(t, len(ids), index.Len())
+
+	assert.Equal(t, ids, index.IDs())
+
+	for _, expected := range full.Comments {
+		comment, err := index.Comment(expected.ID)
+
+		assert.Nil(t, err)
+
+		if assert.NotNil(t, comment) {
+			assert.Equal(t, expected, *comment)
+		}
+	}
+
+	// the fields derived from the comments are left empty
+	full.Comments = nil
+	full.Participants = nil
+	full.TopLevelCommentCount = 0
+
+	assert.Equal(t, full, item)
+}
+
+// TestParseIndexNotFound tests that requesting a comment that
+// the document does not hold reports ErrCommentNotFound.
+func TestParseIndexNotFound(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_job.html"))
+
+	assert.Nil(t, err)
+
+	_, index, err := parser.ParseIndex(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 0, index.Len())
+
+	comment, err := index.Comment(3067434)
+
+	assert.ErrorIs(t, err, parser.ErrCommentNotFound)
+
+	assert.Nil(t, comment)
+
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample_notfound.html"))
+
+	assert.Nil(t, err)
+
+	_, index, err = parser.ParseIndex(bytes.NewReader(sample))
+
+	assert.ErrorIs(t, err, parser.ErrItemNotFound)
+
+	assert.Nil(t, index)
+}
+
+// BenchmarkParseIndex benchmarks indexing a thread with over a
+// hundred comments and extracting a single one of them.
+func BenchmarkParseIndex(b *testing.B) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(b, err)
+
+	b.ReportAllocs()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, index, err := parser.ParseIndex(bytes.NewReader(sample))
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := index.Comment(3068693); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -32,9 +32,15 @@ package parser
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/url"
+	"path"
 	"regexp"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -43,305 +49,2357 @@ import (
 	"golang.org/x/net/html"
 )
 
-// dateLayout specifies the date layout constant to use.
-const dateLayout = "2006-01-02T15:04:05"
+// dateLayouts specifies the layouts of the dates that HN renders in the title
+// attribute of "age" nodes, in order of preference: the current layout, which
+// carries no UTC offset, followed by that of dates which do. Either accepts
+// fractional seconds. The Unix timestamp that may follow the date is split off
+// beforehand, as no layout can hold it.
+var dateLayouts = []string{
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// ctxCheckInterval specifies how many nodes are visited between
+// checks of the context for cancellation.
+const ctxCheckInterval = 256
+
+// deadModifier specifies the "commtext" modifier class
+// that HN uses to render the text of dead comments.
+const deadModifier = "cdd"
+
+// fadeModifiers specifies the "commtext" color modifier classes
+// that HN uses for increasingly downvoted comments, from full
+// color to the color of dead comments.
+var fadeModifiers = []string{"c00", "c5a", "c73", "c82", "c88", "c9c", "cae", "cbe", "cce", deadModifier}
+
+// deadMarkers specifies the markers that HN displays
+// for dead or flagged comments.
+var deadMarkers = []string{"[dead]", "[flagged]"}
+
+// whitespaceRegex matches runs of whitespace. It is compiled
+// once, as fixText is called for nearly every extracted field.
+var whitespaceRegex = regexp.MustCompile(`\s+`)
+
+// numberRegex matches the integer of a score or of a count,
+// wherever it lies in the text and however many words surround
+// it, with any thousands separators.
+var numberRegex = regexp.MustCompile(`\d[\d,]*`)
+
+// collapsedModifier specifies the comment row modifier
+// class that HN uses to mark collapsed comments.
+const collapsedModifier = "coll"
+
+// hiddenRepliesRegex matches the number of replies hidden
+// by a collapsed comment, as shown by its toggle.
+var hiddenRepliesRegex = regexp.MustCompile(`\[(\d+) more\]`)
+
+// replyCountRegex matches the number of direct replies to a
+// comment, as annotated by some HN-compatible renderers, in
+// both its singular and plural forms.
+var replyCountRegex = regexp.MustCompile(`^\s*(\d+)\s+repl(?:y|ies)\s*$`)
+
+// sublineActions specifies the prefixes of the standard links
+// that HN displays in the subline of an item, such as those to
+// hide or flag it.
+var sublineActions = []string{"hide?", "flag?", "unflag?", "fave?", "vote?", "edit?", "delete-confirm?", "from?"}
+
+// pageTitleSuffix specifies the suffix that HN appends
+// to the title of its pages.
+const pageTitleSuffix = " | Hacker News"
+
+// rateLimitMessage specifies the message of the page that
+// HN serves when requests are made too quickly.
+const rateLimitMessage = "Sorry, we're not able to serve your requests this"
+
+// validationMessage specifies the message of the page that
+// HN serves when a client must acquire a cookie to proceed.
+const validationMessage = "Validation required"
+
+// spacerImage specifies the image that HN lays
+// out in place of missing elements.
+const spacerImage = "s.gif"
+
+// notFoundMessage specifies the message of the page that
+// HN serves for items that do not exist.
+const notFoundMessage = "No such item."
+
+// relativeAgeRegex matches the relative age text that HN
+// renders for recent items and comments (e.g. "5 hours ago").
+var relativeAgeRegex = regexp.MustCompile(`^(\d+)\s+(second|minute|hour|day|month|year)s?\s+ago$`)
+
+// absoluteAgeLayout specifies the layout of the age text that
+// HN renders for older items and comments (e.g. "on Oct 3, 2011").
+const absoluteAgeLayout = "on Jan 2, 2006"
+
+// indentWidth specifies the width, in pixels, of the spacer
+// image that HN uses for a single level of comment nesting.
+const indentWidth = 40
 
 // ParseHTML parses an HTML document from the provided io.Reader and populates
 // a model.Item struct with the relevant data extracted from the document.
 // It returns a pointer to the populated model.Item and an error if parsing
 // fails or if any issues occur during the node traversal process.
+// ParseHTML uses a Parser with the default options.
 func ParseHTML(doc io.Reader) (*model.Item, error) {
-	var item model.Item
+	return defaultParser.ParseHTML(doc)
+}
+
+// ParseBytes behaves like ParseHTML, but parses the HTML document held by the
+// provided byte slice, such as a page saved to disk. ParseBytes uses a Parser
+// with the default options.
+func ParseBytes(b []byte) (*model.Item, error) {
+	return defaultParser.ParseBytes(b)
+}
+
+// ParseHTMLWithContext behaves like ParseHTML, but periodically checks the
+// provided context during the node traversal and returns the context's error
+// as soon as it is cancelled or its deadline is exceeded.
+func ParseHTMLWithContext(ctx context.Context, doc io.Reader) (*model.Item, error) {
+	return defaultParser.ParseHTMLWithContext(ctx, doc)
+}
+
+// ParseHTMLWithBase behaves like ParseHTML, but resolves relative references,
+// such as the link to the next page of comments, against the provided base URL.
+func ParseHTMLWithBase(doc io.Reader, base *url.URL) (*model.Item, error) {
+	return defaultParser.ParseHTMLWithBase(doc, base)
+}
+
+// ParseHTML parses an HTML document from the provided io.Reader and populates
+// a model.Item struct with the relevant data extracted from the document.
+// It returns a pointer to the populated model.Item and an error if parsing
+// fails or if any issues occur during the node traversal process.
+func (p *Parser) ParseHTML(doc io.Reader) (*model.Item, error) {
+	return p.ParseHTMLWithContext(context.Background(), doc)
+}
 
-	node, err := html.Parse(doc)
+// ParseBytes behaves like ParseHTML, but parses the HTML document held by the
+// provided byte slice, such as a page saved to disk. The slice is read in place,
+// without being copied, and must not be modified while it is being parsed.
+func (p *Parser) ParseBytes(b []byte) (*model.Item, error) {
+	var reader bytes.Reader
+
+	reader.Reset(b)
+
+	return p.ParseHTML(&reader)
+}
+
+// ParseHTMLWithBase behaves like ParseHTML, but resolves relative references,
+// such as the link to the next page of comments, against the provided base URL
+// rather than the base URL of the Parser.
+func (p *Parser) ParseHTMLWithBase(doc io.Reader, base *url.URL) (*model.Item, error) {
+	basedParser := *p
+	basedParser.base = base
+
+	return basedParser.ParseHTML(doc)
+}
+
+// ParseHTMLWithContext behaves like ParseHTML, but periodically checks the
+// provided context during the node traversal and returns the context's error
+// as soon as it is cancelled or its deadline is exceeded.
+func (p *Parser) ParseHTMLWithContext(ctx context.Context, doc io.Reader) (item *model.Item, err error) {
+	var visited int
+
+	// the statistics are recorded last, so
+	// that they include a recovered panic
+	if p.stats != nil {
+		start := time.Now()
+
+		defer func() {
+			p.stats.recordParse(visited, item, err, time.Since(start))
+		}()
+	}
+
+	defer recoverPanic(&err)
+
+	item = &model.Item{}
+
+	node, err := p.parseDocument(doc)
 	if err != nil {
 		return nil, err
 	}
 
-	err = nodeTraverser(node, &item)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	return &item, err
-}
+	if err := p.checkErrorPage(node); err != nil {
+		return nil, err
+	}
 
-// nodeTraverser recursively traverses an HTML node tree, processing each node
-// that meets specific criteria and populating the provided model.Item struct
-// with the relevant data. The function returns an error if any issues occur
-// during the traversal or processing of nodes.
-func nodeTraverser(node *html.Node, item *model.Item) error {
-	if node.Type == html.ElementNode && shouldProcess(node) {
-		err := processNode(node, item)
+	// the replies on the permalink page of a comment
+	// are nested beneath it as they are visited
+	focused, err := p.findFocusedComment(node)
 
-		if err != nil {
-			return err
+	if err != nil {
+		return item, err
+	}
+
+	builder := &itemBuilder{p: p, shown: make(map[int]bool)}
+
+	if focused != nil && !p.skipComments {
+		builder.focus(*focused, p.replyCountNode(p.focusedCommentRow(node)) != nil)
+	}
+
+	err = p.walkNode(ctx, node, item, builder, &visited)
+
+	if err == nil {
+		inferKind(item)
+
+		if focused != nil {
+			err = p.focusItem(item, focused)
 		}
 	}
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		err := nodeTraverser(child, item)
+	// the comments collected so far are kept on error
+	builder.build(item)
 
-		if err != nil {
-			return err
+	if err != nil {
+		return item, err
+	}
+
+	if err := p.extractOpenGraph(node, item); err != nil {
+		return item, err
+	}
+
+	extractPageTitle(node, item)
+
+	if p.strict {
+		return item, checkRequiredFields(item)
+	}
+
+	return item, nil
+}
+
+// parseDocument parses an HTML document from the provided io.Reader. When the
+// Parser was configured with a maximum size, at most one byte beyond it is read,
+// and an error wrapping ErrDocumentTooLarge is returned if the document exceeds
+// it, so that an oversized document never grows an unbounded node tree.
+func (p *Parser) parseDocument(doc io.Reader) (*html.Node, error) {
+	var node *html.Node
+
+	err := p.readLimited(doc, func(r io.Reader) (err error) {
+		node, err = html.Parse(r)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// readLimited calls parse with a reader of the provided document, which reads at
+// most one byte beyond the maximum size of the Parser, if any. Returns the error
+// returned by parse, or an error wrapping ErrDocumentTooLarge if the document
+// exceeds the maximum size.
+func (p *Parser) readLimited(doc io.Reader, parse func(io.Reader) error) error {
+	if p.maxBytes <= 0 {
+		return parse(doc)
+	}
+
+	// read one extra byte to detect that the
+	// document goes on beyond the limit
+	limited := &io.LimitedReader{R: doc, N: p.maxBytes + 1}
+
+	if err := parse(limited); err != nil {
+		return err
+	}
+
+	if limited.N == 0 {
+		return fmt.Errorf("%w: exceeds %d bytes", ErrDocumentTooLarge, p.maxBytes)
+	}
+
+	return nil
+}
+
+// checkRequiredFields checks that the title, ID, and date of the provided item,
+// along with its score and author unless it is a job posting, were found during
+// parsing. On the permalink page of a comment, which shows only the ID and title
+// of the story of the comment, only those are required. Returns an error wrapping
+// ErrMissingFields that names the missing fields otherwise.
+func checkRequiredFields(item *model.Item) error {
+	var missing []string
+
+	if !item.Found.Title {
+		missing = append(missing, "title")
+	}
+
+	if !item.Found.ID {
+		missing = append(missing, "id")
+	}
+
+	focused := item.FocusedCommentID != nil
+
+	if !focused && !item.Found.Date {
+		missing = append(missing, "date")
+	}
+
+	if !focused && !item.IsJob && !item.Found.Score {
+		missing = append(missing, "score")
+	}
+
+	if !focused && !item.IsJob && !item.Found.Author {
+		missing = append(missing, "author")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingFields, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// recoverPanic recovers from a panic during parsing, if any, and stores it into
+// the provided error as a *PanicError. It must be deferred directly.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
+
+// checkErrorPage checks whether the provided document is one of the pages that HN
+// serves in place of the requested page. Returns ErrRateLimited for the rate-limit
+// page, ErrValidationRequired for the "Validation required" interstitial,
+// ErrItemNotFound for the "No such item." page, and nil otherwise.
+func (p *Parser) checkErrorPage(node *html.Node) error {
+	// the rate-limit page and the validation interstitial are
+	// bare messages, without any of the usual page layout
+	if getChildRefByID(node, "hnmain") == nil {
+		text := fixText(getText(node))
+
+		if strings.Contains(text, rateLimitMessage) {
+			return ErrRateLimited
+		}
+
+		if strings.Contains(text, validationMessage) {
+			return ErrValidationRequired
 		}
+
+		return nil
+	}
+
+	// any item or comment row rules out the
+	// "No such item." page
+	hasRow := getChildRefByClass(node, p.classes.Item)
+
+	if hasRow != nil {
+		return nil
+	}
+
+	notFound := getChildRefByPredicate(node, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "td" && strings.TrimSpace(getText(n)) == notFoundMessage
+	})
+
+	if notFound != nil {
+		return ErrItemNotFound
 	}
 
 	return nil
 }
 
-// shouldProcess checks if a given HTML node is one of the specified element types
-// ("td", "tr", "span", "a", "table") that should be processed for data extraction.
-// Returns true if the node matches one of these types, false otherwise.
-func shouldProcess(node *html.Node) bool {
-	return node.Data == "td" ||
-		node.Data == "tr" ||
-		node.Data == "span" ||
-		node.Data == "a" ||
-		node.Data == "table"
+// shouldProcess checks if a given HTML node is one of the element types the
+// Parser was configured with (by default "td", "tr", "span", "a", "table") that
+// should be processed for data extraction. Returns true if the node matches one
+// of these types, false otherwise.
+func (p *Parser) shouldProcess(node *html.Node) bool {
+	_, ok := p.elements[node.Data]
+
+	return ok
 }
 
 // processNode processes a given HTML node to extract and populate various fields
 // of a model.Item struct, such as the title, ID, score, date, author, and comments.
 // The function returns an error if any of the extraction operations fail.
-func processNode(node *html.Node, item *model.Item) error {
+func (p *Parser) processNode(node *html.Node, item *model.Item) error {
 	// process the title
-	if err := extractTitle(node, item); err != nil {
+	if err := p.extractTitle(node, item); err != nil {
 		return err
 	}
 
 	// process the ID
-	if err := extractID(node, item); err != nil {
+	if err := p.extractID(node, item); err != nil {
 		return err
 	}
 
+	// process the domain
+	if err := p.extractDomain(node, item); err != nil {
+		return err
+	}
+
+	// process the rank
+	if err := p.extractRank(node, item); err != nil {
+		return err
+	}
+
+	// process the vote arrow
+	if err := p.extractVoteable(node, item); err != nil {
+		return err
+	}
+
+	// process the favicon of the site
+	if err := p.extractFavicon(node, item); err != nil {
+		return err
+	}
+
+	// job postings have no subline, so their
+	// date lies directly in the subtext
+	if classIs(node.Parent, p.classes.Subtext) {
+		// process the date
+		if err := p.extractDate(node, item); err != nil {
+			return err
+		}
+	}
+
+	// the subtext of a job posting has no score
+	if classIs(node, p.classes.Subtext) {
+		// process the job layout
+		if err := p.extractJob(node, item); err != nil {
+			return err
+		}
+
+		// process the hide, flag, edit, and delete links
+		if err := p.extractActionLinks(node, item); err != nil {
+			return err
+		}
+	}
+
 	// the subline parent contains all of the
 	// score, date, and author
-	if classIs(node.Parent, "subline") {
+	if classIs(node.Parent, p.classes.Subline) {
 		// process the score
-		if err := extractScore(node, item); err != nil {
+		if err := p.extractScore(node, item); err != nil {
 			return err
 		}
 
 		// process the date
-		if err := extractDate(node, item); err != nil {
+		if err := p.extractDate(node, item); err != nil {
 			return err
 		}
 
 		// process the author
-		if err := extractAuthor(node, item); err != nil {
+		if err := p.extractAuthor(node, item); err != nil {
+			return err
+		}
+
+		// process the comment count
+		if err := p.extractCommentCount(node, item); err != nil {
+			return err
+		}
+	}
+
+	// the subline may link to past discussions
+	// among its standard links
+	if classIs(node, p.classes.Subline) {
+		// report the fields missing from the subline
+		p.checkSubline(node, item)
+
+		// process the related discussions
+		if err := p.extractRelatedDiscussions(node, item); err != nil {
 			return err
 		}
 	}
 
-	// this is where the comments lie
-	if classIs(node, "comment-tree") {
-		// process the comments
-		extractComments(node, item)
+	// the self-post text lives in the item table
+	if classIs(node, p.classes.FatItem) {
+		// process the text
+		if err := p.extractItemText(node, item); err != nil {
+			return err
+		}
+
+		// process the comment layout
+		if err := p.extractCommentKind(node, item); err != nil {
+			return err
+		}
+	}
+
+	// each poll option lies in its own row
+	if classIs(node, p.classes.Item+" "+p.classes.PollOption) {
+		// process the poll option
+		if err := p.extractPollOption(node, item); err != nil {
+			return err
+		}
+	}
+
+	// the link to the next page follows the comments
+	if classIs(node, p.classes.MoreLink) {
+		// process the next page
+		if err := p.extractNextPage(node, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markOP marks the provided comment as written by the original poster when its
+// author is the provided author of the item. Comments of an item whose author is
+// unknown, such as a job posting, are never marked.
+func markOP(comment *model.Comment, author string) {
+	comment.IsOP = author != "" && comment.Author == author
+}
+
+// countReplies counts the direct replies to each of the provided comments among
+// the comments themselves, following their ParentID, and assigns the count to
+// those whose ID is not in the provided set of comments whose markup shows it.
+// Replies that were not parsed, such as those beyond the maximum number of
+// comments, are not counted.
+func countReplies(comments []model.Comment, shown map[int]bool) {
+	counts := make(map[int]int)
+
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			counts[*comment.ParentID]++
+		}
+	}
+
+	for i := range comments {
+		if !shown[comments[i].ID] {
+			comments[i].ReplyCount = counts[comments[i].ID]
+		}
+	}
+}
+
+// countTopLevelComments returns the number of comments of the provided item that
+// reply to the item directly: those whose parent is the item, along with those at
+// the top of the thread, which have no parent link at all.
+func countTopLevelComments(item *model.Item) int {
+	var count int
+
+	for _, comment := range item.Comments {
+		if comment.ParentID != nil {
+			if *comment.ParentID == item.ID {
+				count++
+			}
+
+			continue
+		}
+
+		if comment.Depth == 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+// collectParticipants returns the distinct authors of the provided item and its
+// comments, in order of appearance, starting with the author of the item. Deleted
+// comments, which have no author, are skipped.
+func collectParticipants(item *model.Item) []string {
+	var participants []string
+
+	seen := make(map[string]struct{})
+
+	add := func(author string) {
+		if _, ok := seen[author]; ok || author == "" {
+			return
+		}
+
+		seen[author] = struct{}{}
+
+		participants = append(participants, author)
+	}
+
+	add(item.Author)
+
+	for _, comment := range item.Comments {
+		add(comment.Author)
+	}
+
+	return participants
+}
+
+// visitComments extracts and parses each comment within a "comment-tree" structure
+// in document order, calling visit with each of them, along with whether its markup
+// shows its number of replies. Comments nested deeper than
+// the maximum depth of the Parser are skipped without being extracted, and once the
+// maximum number of comments of the Parser has been visited, the remaining comments
+// are skipped; either way, visitComments reports that the comments were truncated.
+// Returns an error if any issues arise during comment extraction, or the first error
+// returned by visit.
+func (p *Parser) visitComments(node *html.Node, visit func(comment *model.Comment, shown bool) error) (bool, error) {
+	if node == nil || node.FirstChild == nil || !classIs(node, p.classes.CommentTree) {
+		return false, nil
+	}
+
+	commentChild := getChildRefByPredicate(node, p.isCommentRow)
+
+	if commentChild == nil {
+		return false, nil
+	}
+
+	// make sure we are scanned to the exact one
+	for commentChild.PrevSibling != nil {
+		commentChild = commentChild.PrevSibling
+	}
+
+	var visited int
+
+	var tooDeep bool
+
+	for child := commentChild; child != nil; child = child.NextSibling {
+		if p.maxComments > 0 && visited == p.maxComments {
+			// stop at the first comment beyond the limit
+			if p.isCommentRow(child) {
+				return true, nil
+			}
+
+			continue
+		}
+
+		// the depth is read off the indentation alone, so
+		// that deep subtrees are never extracted; a depth
+		// that cannot be parsed is left to extractComment
+		if p.maxDepth >= 0 && p.isCommentRow(child) {
+			if depth, err := p.commentRowDepth(child); err == nil && depth > p.maxDepth {
+				tooDeep = true
+
+				continue
+			}
+		}
+
+		comment, err := p.extractComment(child)
+
+		if err != nil {
+			return false, err
+		}
+
+		if comment == nil {
+			continue
+		}
+
+		if err := visit(comment, p.replyCountNode(child) != nil); err != nil {
+			return false, err
+		}
+
+		visited++
+	}
+
+	return tooDeep, nil
+}
+
+// extractComment extracts and parses a single comment from an HTML node, populating
+// a model.Comment struct with the relevant data such as ID, author, date, parent ID,
+// and content. Returns a pointer to the populated model.Comment and an error if any
+// issues occur during the parsing process.
+func (p *Parser) extractComment(node *html.Node) (*model.Comment, error) {
+	var comment model.Comment
+
+	if node == nil || !p.isCommentRow(node) {
+		return nil, nil
+	}
+
+	if err := p.extractCommentID(node, &comment); err != nil {
+		return nil, err
+	}
+
+	// scan to here to improve efficiency
+	defaultNode := getChildRefByClass(node, p.classes.CommentCell)
+
+	if defaultNode == nil {
+		p.logSkip("comment", fmt.Sprintf("comment %d has no %q cell", comment.ID, p.classes.CommentCell))
+
+		return nil, nil
+	}
+
+	// the ID is known from here on, so include it
+	// in any error to help locate the comment
+	if err := p.extractCommentFields(node, &comment); err != nil {
+		return nil, &CommentError{ID: comment.ID, Err: err}
+	}
+
+	return &comment, nil
+}
+
+// commentRowDepth returns the nesting depth of the comment of the provided row,
+// as extracted by extractCommentDepth. Returns a *ParseError if the depth cannot be
+// parsed.
+func (p *Parser) commentRowDepth(row *html.Node) (int, error) {
+	var comment model.Comment
+
+	err := p.extractCommentDepth(row, &comment)
+
+	return comment.Depth, err
+}
+
+// extractCommentFields extracts and parses the fields of a single comment, other
+// than its ID, from an HTML node and assigns them to the model.Comment struct.
+// Returns an error if any issues occur during the parsing process.
+func (p *Parser) extractCommentFields(node *html.Node, comment *model.Comment) error {
+	if err := p.extractCommentAuthor(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractCommentDate(node, comment); err != nil {
+		return err
+	}
+
+	if err := extractParentID(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractCommentDepth(node, comment); err != nil {
+		return err
+	}
+
+	p.extractNavLinks(node, comment)
+
+	// the links and the raw HTML must be extracted before
+	// the content is rendered, as rendering clears the
+	// attributes
+	if err := p.extractLinks(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractRawHTML(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractContent(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractDead(node, comment); err != nil {
+		return err
+	}
+
+	// the dead marker must be known to count the words
+	countWords(comment)
+
+	if err := p.extractCommentScore(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractCollapsed(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractReplyCount(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractFadeLevel(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractOnStory(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractReplyURL(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractPermalinkURL(node, comment); err != nil {
+		return err
+	}
+
+	if err := p.extractCommentActionLinks(node, comment); err != nil {
+		return err
+	}
+
+	if err := extractCommentVoteable(node, comment); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// extractCommentID extracts the comment ID from the provided HTML node and assigns it
+// to the model.Comment struct. Returns an error if the ID cannot be parsed.
+func (p *Parser) extractCommentID(node *html.Node, comment *model.Comment) error {
+	if node == nil || !p.isCommentRow(node) {
+		return nil
+	}
+
+	idString := getAttr(node, "id")
+
+	id, err := strconv.Atoi(idString)
+
+	if err != nil {
+		return &ParseError{Field: "comment id", Raw: idString, Err: err}
+	}
+
+	comment.ID = id
+
+	return nil
+}
+
+// extractParentID extracts the parent ID of a comment from its "parent" link, if
+// it exists, and assigns it to the model.Comment struct. The link may point at
+// the parent either on the same page (e.g. "#3067434") or on a page of its own
+// (e.g. "item?id=3067434#3067434"). A malformed link leaves the parent ID unset
+// rather than failing the parse of the whole page.
+func extractParentID(node *html.Node, comment *model.Comment) error {
+	parentNode := getChildRefByData(node, "parent")
+
+	if parentNode == nil {
+		return nil
+	}
+
+	parent := parentNode.Parent
+
+	ref := getAttr(parent, "href")
+
+	if ref == "" {
+		return nil
+	}
+
+	if pid, ok := parseParentRef(ref); ok {
+		comment.ParentID = &pid
+	}
+
+	return nil
+}
+
+// extractNavLinks extracts the IDs of the comments that the "root", "next", and
+// "prev" links of the "navs" span of a comment point at, and assigns them to the
+// model.Comment struct. As with the parent link, each may point at its comment on
+// the same page or on a page of its own. Absent or malformed links leave their ID
+// unset.
+func (p *Parser) extractNavLinks(node *html.Node, comment *model.Comment) {
+	navsNode := getChildRefByClass(node, p.classes.Navs)
+
+	if navsNode == nil {
+		return
+	}
+
+	targets := map[string]**int{
+		"root": &comment.RootID,
+		"next": &comment.NextID,
+		"prev": &comment.PrevID,
+	}
+
+	traverseNode(navsNode, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.Data != "a" {
+			return
+		}
+
+		target, ok := targets[strings.TrimSpace(getText(n))]
+
+		if !ok {
+			return
+		}
+
+		if id, ok := parseParentRef(getAttr(n, "href")); ok {
+			*target = &id
+		}
+	})
+}
+
+// parseParentRef parses the ID of the parent that the provided reference points
+// at, held by its "id" query parameter or, failing that, by its fragment. Returns
+// false if the reference holds no such ID.
+func parseParentRef(ref string) (int, bool) {
+	parsed, err := url.Parse(ref)
+
+	if err != nil {
+		return 0, false
+	}
+
+	if id, err := strconv.Atoi(parsed.Query().Get("id")); err == nil {
+		return id, true
+	}
+
+	if id, err := strconv.Atoi(parsed.Fragment); err == nil {
+		return id, true
+	}
+
+	return 0, false
+}
+
+// extractCommentScore extracts and parses the score of a comment from the "score"
+// span within the comment header, if it exists, and assigns it to the model.Comment
+// struct. Returns an error if the score cannot be parsed.
+func (p *Parser) extractCommentScore(node *html.Node, comment *model.Comment) error {
+	headNode := getChildRefByClass(node, p.classes.CommentHead)
+
+	if headNode == nil {
+		return nil
+	}
+
+	scoreNode := getChildRefByClass(headNode, p.classes.Score)
+
+	if scoreNode == nil || scoreNode.FirstChild == nil {
+		return nil
+	}
+
+	points, err := parseScore(fixText(scoreNode.FirstChild.Data))
+
+	if err != nil {
+		return err
+	}
+
+	comment.Points = &points
+
+	return nil
+}
+
+// extractCollapsed determines whether a comment has been collapsed, along with the
+// number of replies hidden beneath it from its "[N more]" toggle, and assigns them
+// to the model.Comment struct. Returns an error if the number cannot be parsed.
+func (p *Parser) extractCollapsed(node *html.Node, comment *model.Comment) error {
+	if !classIs(node, collapsedModifier) {
+		return nil
+	}
+
+	comment.Collapsed = true
+
+	toggleNode := getChildRefByClass(node, p.classes.Toggle)
+
+	if toggleNode == nil {
+		return nil
+	}
+
+	match := hiddenRepliesRegex.FindStringSubmatch(getText(toggleNode))
+
+	if match == nil {
+		return nil
+	}
+
+	hidden, err := strconv.Atoi(match[1])
+
+	if err != nil {
+		return &ParseError{Field: "hidden replies", Raw: match[0], Err: err}
+	}
+
+	comment.HiddenReplies = hidden
+
+	return nil
+}
+
+// extractReplyCount extracts the number of direct replies to a comment from the
+// "N replies" annotation of its header, which some HN-compatible renderers show,
+// and assigns it to the model.Comment struct. HN itself shows no such annotation,
+// leaving the comment untouched. Returns a *ParseError if the number cannot be
+// parsed.
+func (p *Parser) extractReplyCount(node *html.Node, comment *model.Comment) error {
+	countNode := p.replyCountNode(node)
+
+	if countNode == nil {
+		return nil
+	}
+
+	match := replyCountRegex.FindStringSubmatch(getText(countNode))
+
+	count, err := strconv.Atoi(match[1])
+
+	if err != nil {
+		return &ParseError{Field: "reply count", Raw: match[0], Err: err}
+	}
+
+	comment.ReplyCount = count
+
+	return nil
+}
+
+// replyCountNode returns the "N replies" annotation of the header of the provided
+// comment row, or nil if the markup shows none, in which case the number of replies
+// to the comment is derived from those parsed alongside it.
+func (p *Parser) replyCountNode(row *html.Node) *html.Node {
+	headNode := getChildRefByClass(row, p.classes.CommentHead)
+
+	if headNode == nil {
+		return nil
+	}
+
+	return getChildRefByPredicate(headNode, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && replyCountRegex.MatchString(getText(n))
+	})
+}
+
+// extractFadeLevel extracts how faded the text of a comment is rendered, which
+// reflects how downvoted the comment is, from the color modifier class of its
+// "commtext" node and assigns it to the model.Comment struct. Unknown modifiers
+// leave the comment at full color.
+func (p *Parser) extractFadeLevel(node *html.Node, comment *model.Comment) error {
+	textNode := p.getCommentTextNode(node)
+
+	if textNode == nil {
+		return nil
+	}
+
+	// the modifier may precede or follow the class of the text
+	for _, class := range strings.Fields(getAttr(textNode, "class")) {
+		if class == p.classes.CommentText {
+			continue
+		}
+
+		if level := slices.Index(fadeModifiers, class); level != -1 {
+			comment.FadeLevel = level
+
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// extractOnStory extracts the story that a comment was made on from the "on:" link
+// of the comment header, which HN shows when the comment appears outside of its
+// thread, and assigns it to the model.Comment struct. Returns an error if the ID of
+// the story cannot be parsed.
+func (p *Parser) extractOnStory(node *html.Node, comment *model.Comment) error {
+	onStoryNode := getChildRefByClass(node, p.classes.OnStory)
+
+	if onStoryNode == nil {
+		return nil
+	}
+
+	storyNode := getChildRefByData(onStoryNode, "a")
+
+	if storyNode == nil {
+		return nil
+	}
+
+	storyID, err := parseIDParam(getAttr(storyNode, "href"))
+
+	if err != nil {
+		return err
+	}
+
+	comment.StoryID = &storyID
+	comment.StoryTitle = cleanText(getText(storyNode))
+
+	return nil
+}
+
+// extractReplyURL extracts the URL of the reply link in the footer of a comment,
+// resolved against the base URL, and assigns it to the model.Comment struct. The
+// link is absent when replying is unavailable (e.g. on archived pages), leaving the
+// comment untouched. Returns a *ParseError if the URL cannot be parsed.
+func (p *Parser) extractReplyURL(node *html.Node, comment *model.Comment) error {
+	replyNode := getChildRefByClass(node, p.classes.Reply)
+
+	if replyNode == nil {
+		return nil
+	}
+
+	anchorNode := getChildRefByData(replyNode, "a")
+
+	if anchorNode == nil {
+		return nil
+	}
+
+	href := getAttr(anchorNode, "href")
+
+	if href == "" {
+		return nil
+	}
+
+	replyURL, err := p.resolve(href)
+
+	if err != nil {
+		return &ParseError{Field: "reply", Raw: href, Err: err}
+	}
+
+	comment.ReplyURL = replyURL
+
+	return nil
+}
+
+// extractPermalinkURL extracts the URL of the page of a comment from the link of
+// its age, resolved against the base URL, and assigns it to the model.Comment
+// struct. Returns a *ParseError if the URL cannot be parsed.
+func (p *Parser) extractPermalinkURL(node *html.Node, comment *model.Comment) error {
+	anchorNode := getChildRefByData(getChildRefByClass(node, p.classes.Age), "a")
+
+	if anchorNode == nil {
+		return nil
+	}
+
+	href := getAttr(anchorNode, "href")
+
+	if href == "" {
+		return nil
+	}
+
+	permalinkURL, err := p.resolve(href)
+
+	if err != nil {
+		return &ParseError{Field: "permalink", Raw: href, Err: err}
+	}
+
+	comment.PermalinkURL = permalinkURL
+
+	return nil
+}
+
+// extractCommentVoteable determines whether the provided comment row carries the
+// upvote arrow of the comment, and assigns the result to the model.Comment struct.
+func extractCommentVoteable(node *html.Node, comment *model.Comment) error {
+	comment.Voteable = getChildRefByID(node, "up_"+strconv.Itoa(comment.ID)) != nil
+
+	return nil
+}
+
+// extractCommentDepth extracts the nesting depth of a comment from the width of
+// the spacer image inside the "ind" cell and assigns it to the model.Comment struct.
+// Comments without a spacer image are treated as top-level. Returns a *ParseError if
+// the width cannot be parsed.
+func (p *Parser) extractCommentDepth(node *html.Node, comment *model.Comment) error {
+	indNode := getChildRefByClass(node, p.classes.Indent)
+
+	if indNode == nil {
+		return nil
+	}
+
+	imgNode := getChildRefByData(indNode, "img")
+
+	if imgNode == nil {
+		return nil
+	}
+
+	widthString := getAttr(imgNode, "width")
+
+	if widthString == "" {
+		return nil
+	}
+
+	width, err := strconv.Atoi(widthString)
+
+	if err != nil {
+		return &ParseError{Field: "depth", Raw: widthString, Err: err}
+	}
+
+	comment.Depth = width / indentWidth
+
+	return nil
+}
+
+// extractLinks collects the distinct hrefs of the anchors within the content of a
+// comment, skipping HN's reply links, resolves them against the base URL, rewrites
+// them with the URL sanitizer, if any, and assigns them to the model.Comment struct.
+// Links that cannot be parsed are kept verbatim.
+func (p *Parser) extractLinks(node *html.Node, comment *model.Comment) error {
+	contentNode := p.getCommentTextNode(node)
+
+	if contentNode == nil {
+		return nil
+	}
+
+	var links []string
+
+	traverseNode(contentNode, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.Data != "a" {
+			return
+		}
+
+		href := getAttr(n, "href")
+
+		if href == "" || strings.HasPrefix(href, "reply?") {
+			return
+		}
+
+		// links that users paste are not always valid
+		// URLs, in which case they are kept verbatim
+		if link, err := p.resolve(href); err == nil {
+			if link = p.sanitize(link); link == nil {
+				return
+			}
+
+			href = link.String()
+		}
+
+		if slices.Contains(links, href) {
+			return
+		}
+
+		links = append(links, href)
+	})
+
+	comment.Links = links
+
+	return nil
+}
+
+// extractRawHTML renders the content of a comment from the provided HTML node as
+// it appears on the page, keeping its attributes and its whitespace (such as that
+// of <pre><code> blocks), and assigns it to the model.Comment struct. It is only
+// extracted when the Parser was configured to keep the raw HTML. Returns an error
+// if the content cannot be rendered.
+func (p *Parser) extractRawHTML(node *html.Node, comment *model.Comment) error {
+	if !p.rawHTML {
+		return nil
+	}
+
+	contentNode := p.getCommentTextNode(node)
+
+	if contentNode == nil {
+		return nil
+	}
+
+	rawHTML, err := renderRawHTML(contentNode)
+
+	if err != nil {
+		return err
+	}
+
+	comment.RawHTML = rawHTML
+
+	return nil
+}
+
+// renderRawHTML renders the provided HTML node as it appears on the page, keeping
+// its attributes and its whitespace. Returns an error if rendering fails.
+func renderRawHTML(node *html.Node) (string, error) {
+	var buf bytes.Buffer
+
+	if err := html.Render(&buf, node); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// extractContent extracts the content of a comment from the provided HTML node and
+// assigns it to the model.Comment struct. The content is cleaned by the cleaner the
+// Parser was configured with, which receives the raw HTML of the content, and by
+// renderCommentContent otherwise. Returns an error if content extraction fails.
+func (p *Parser) extractContent(node *html.Node, comment *model.Comment) error {
+	contentNode := p.getCommentTextNode(node)
+
+	if contentNode == nil {
+		return nil
+	}
+
+	if p.contentCleaner != nil {
+		rawHTML, err := renderRawHTML(contentNode)
+
+		if err != nil {
+			return err
+		}
+
+		comment.Content = p.contentCleaner(rawHTML)
+
+		return nil
+	}
+
+	content, err := renderCommentContent(contentNode)
+
+	if err != nil {
+		return err
+	}
+
+	comment.Content = content
+
+	return nil
+}
+
+// countWords counts the words of the plain text of the content of the provided
+// comment, ignoring its tags and its whitespace, and assigns the count to the
+// model.Comment struct. Dead comments count zero, as they take no part in the
+// discussion.
+func countWords(comment *model.Comment) {
+	if comment.Dead {
+		comment.WordCount = 0
+
+		return
+	}
+
+	comment.WordCount = len(strings.Fields(comment.PlainText()))
+}
+
+// extractDead determines whether a comment is dead or flagged and assigns the
+// result to the model.Comment struct. A comment is dead when its "commtext" node
+// carries the dead modifier class, or when its header or its body (in the absence
+// of a "commtext" node) displays one of the dead markers.
+func (p *Parser) extractDead(node *html.Node, comment *model.Comment) error {
+	textNode := p.getCommentTextNode(node)
+
+	if classIs(textNode, deadModifier) {
+		comment.Dead = true
+
+		return nil
+	}
+
+	if hasDeadMarker(getChildRefByClass(node, p.classes.CommentHead)) {
+		comment.Dead = true
+
+		return nil
+	}
+
+	if textNode == nil && hasDeadMarker(getChildRefByClass(node, p.classes.CommentBody)) {
+		comment.Dead = true
+	}
+
+	return nil
+}
+
+// hasDeadMarker checks whether the text of the provided HTML node contains one
+// of the dead markers. Returns false if the node is nil.
+func hasDeadMarker(node *html.Node) bool {
+	if node == nil {
+		return false
+	}
+
+	text := getText(node)
+
+	for _, marker := range deadMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// extractItemText extracts the body of a self-post (e.g. Ask HN) from the
+// "toptext" node of the provided HTML node and assigns it to the model.Item
+// struct. Returns an error if the text cannot be rendered.
+func (p *Parser) extractItemText(node *html.Node, item *model.Item) error {
+	textNode := getChildRefByClass(node, p.classes.TopText)
+
+	if textNode == nil {
+		return nil
+	}
+
+	text, err := renderContent(textNode)
+
+	if err != nil {
+		return err
+	}
+
+	item.Text = text
+
+	return nil
+}
+
+// extractCommentKind determines whether the provided "fatitem" HTML node lays out
+// a comment, viewed on its own page, rather than a story, and if so assigns the
+// comment kind to the model.Item struct.
+func (p *Parser) extractCommentKind(node *html.Node, item *model.Item) error {
+	// the options of a poll follow the row
+	// of the item, and carry a comhead too
+	if p.hasCommentHead(getChildRefByClass(node, p.classes.Item)) {
+		item.Kind = model.KindComment
+	}
+
+	return nil
+}
+
+// hasCommentHead checks whether the provided row of the "fatitem" table carries the
+// header of a comment, which is the case when it lays out a comment on its own page.
+func (p *Parser) hasCommentHead(row *html.Node) bool {
+	// the site of a story is displayed in a
+	// "sitebit comhead", which is not a header
+	headNode := getChildRefByPredicate(row, func(n *html.Node) bool {
+		return classIs(n, p.classes.CommentHead) && !classIs(n, p.classes.SiteBit)
+	})
+
+	return headNode != nil
+}
+
+// extractFocusedComment extracts the comment that the provided document focuses on
+// when it is the permalink page of a comment, and gives the provided item the context
+// of its story, as focusItem does. Returns nil if the document is not the page of a
+// comment, or an error if the focused comment cannot be extracted.
+func (p *Parser) extractFocusedComment(node *html.Node, item *model.Item) (*model.Comment, error) {
+	comment, err := p.findFocusedComment(node)
+
+	if err != nil || comment == nil {
+		return nil, err
+	}
+
+	if err := p.focusItem(item, comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// findFocusedComment extracts the comment that the provided document focuses on
+// when it is the permalink page of a comment, whose "fatitem" table lays out the
+// comment rather than its story. Returns nil if the document is not the page of a
+// comment, or an error if the focused comment cannot be extracted.
+func (p *Parser) findFocusedComment(node *html.Node) (*model.Comment, error) {
+	rowNode := p.focusedCommentRow(node)
+
+	if rowNode == nil || !p.hasCommentHead(rowNode) {
+		return nil, nil
+	}
+
+	// the row is laid out as that of an item
+	var row model.Item
+
+	if err := p.extractID(rowNode, &row); err != nil {
+		return nil, err
+	}
+
+	comment := &model.Comment{ID: row.ID}
+
+	if err := p.extractCommentFields(rowNode, comment); err != nil {
+		return nil, &CommentError{ID: comment.ID, Err: err}
+	}
+
+	return comment, nil
+}
+
+// focusItem gives the provided item, extracted from the permalink page of the
+// provided focused comment, the context of the story of the comment, namely its
+// ID and title, as taken from the "on:" link of the comment, along with the ID of
+// the focused comment. The fields extracted from the comment, such as its author,
+// points and date, are dropped and the kind is inferred from the story. The author
+// of the story is unknown, so no comment is marked as written by it. Returns an
+// error if the URL of the story cannot be resolved.
+func (p *Parser) focusItem(item *model.Item, comment *model.Comment) error {
+	item.FocusedCommentID = &comment.ID
+
+	if comment.StoryID == nil {
+		return nil
+	}
+
+	reference, err := p.resolve("item?id=" + strconv.Itoa(*comment.StoryID))
+
+	if err != nil {
+		return err
+	}
+
+	// the fields extracted from the fatitem table are those of the comment, so
+	// the item is rebuilt from the story, keeping only the fields of the page
+	*item = model.Item{
+		ID:                *comment.StoryID,
+		Title:             model.Title{Name: comment.StoryTitle, Reference: reference},
+		Comments:          item.Comments,
+		NextPage:          item.NextPage,
+		Found:             model.FieldsFound{ID: true, Title: comment.StoryTitle != ""},
+		CommentsTruncated: item.CommentsTruncated,
+		CommentRoots:      item.CommentRoots,
+		FocusedCommentID:  &comment.ID,
+	}
+
+	inferKind(item)
+
+	return nil
+}
+
+// focusedCommentRow returns the row of the comment that the provided document
+// focuses on, which the "fatitem" table of the permalink page of a comment lays
+// out in place of its story, or nil if there is none.
+func (p *Parser) focusedCommentRow(node *html.Node) *html.Node {
+	return getChildRefByPredicate(getChildRefByClass(node, p.classes.FatItem), p.isItemRow)
+}
+
+// nestReply nests the provided reply beneath the provided focused comment, shifting
+// its depth by one and, if it lies at the top of the replies, making the focused
+// comment its parent.
+func nestReply(reply *model.Comment, focused *model.Comment) {
+	reply.Depth++
+
+	if reply.ParentID == nil {
+		reply.ParentID = &focused.ID
+	}
+}
+
+// inferKind infers the kind of the provided item from the fields extracted from
+// its page, unless it was already found to be a comment, and assigns it to the
+// model.Item struct. Polls take precedence over the "Ask HN:" and "Show HN:" title
+// prefixes, and any other item is a story.
+func inferKind(item *model.Item) {
+	switch {
+	case item.Kind == model.KindComment:
+		return
+	case item.IsJob:
+		item.Kind = model.KindJob
+	case item.Poll != nil:
+		item.Kind = model.KindPoll
+	case strings.HasPrefix(item.Title.Name, "Ask HN:"):
+		item.Kind = model.KindAsk
+	case strings.HasPrefix(item.Title.Name, "Show HN:"):
+		item.Kind = model.KindShow
+	default:
+		item.Kind = model.KindStory
+	}
+}
+
+// renderContent renders the provided HTML node without its attributes and
+// returns the result with extraneous whitespace removed. Returns an error
+// if the node cannot be rendered.
+func renderContent(node *html.Node) (string, error) {
+	var buf bytes.Buffer
+
+	contentCopy := *node
+
+	clearAttributes(&contentCopy)
+
+	err := html.Render(&buf, &contentCopy)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fixText(buf.String()), nil
+}
+
+// renderCommentContent renders the provided comment content HTML node without its
+// attributes, cleaning its whitespace without destroying its structure: the text of
+// <pre> blocks, such as quoted code, is kept verbatim, paragraphs are separated by
+// blank lines, and runs of whitespace are only collapsed within the rest of the
+// text. The provided node is left untouched. Returns an error if the node cannot
+// be rendered.
+func renderCommentContent(node *html.Node) (string, error) {
+	var buf bytes.Buffer
+
+	contentCopy := cloneNode(node)
+
+	clearAttributes(contentCopy)
+
+	collapseWhitespace(contentCopy)
+
+	separateParagraphs(contentCopy)
+
+	err := html.Render(&buf, contentCopy)
+
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// cloneNode returns a deep copy of the provided HTML node, detached
+// from the tree of the original.
+func cloneNode(node *html.Node) *html.Node {
+	clone := &html.Node{
+		Type:      node.Type,
+		DataAtom:  node.DataAtom,
+		Data:      node.Data,
+		Namespace: node.Namespace,
+		Attr:      slices.Clone(node.Attr),
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		clone.AppendChild(cloneNode(child))
+	}
+
+	return clone
+}
+
+// collapseWhitespace collapses the runs of whitespace within the text nodes
+// beneath the provided HTML node into single spaces - in place. The text of
+// <pre> blocks is left untouched.
+func collapseWhitespace(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.TextNode:
+			child.Data = fixText(child.Data)
+		case child.Type == html.ElementNode && child.Data != "pre":
+			collapseWhitespace(child)
+		}
+	}
+}
+
+// separateParagraphs separates the paragraphs and <pre> blocks directly beneath
+// the provided HTML node with blank lines, trimming the spaces around them and
+// dropping the empty paragraphs that HN leaves before <pre> blocks - in place.
+func separateParagraphs(node *html.Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		isBlock := child.Type == html.ElementNode && (child.Data == "p" || child.Data == "pre")
+
+		if !isBlock {
+			child = next
+
+			continue
+		}
+
+		if child.Data == "p" && strings.TrimSpace(getText(child)) == "" {
+			node.RemoveChild(child)
+
+			child = next
+
+			continue
+		}
+
+		if child.Data == "p" {
+			trimEdges(child)
+		}
+
+		if prev := child.PrevSibling; prev != nil {
+			if prev.Type == html.TextNode {
+				prev.Data = strings.TrimRight(prev.Data, " ")
+			}
+
+			node.InsertBefore(&html.Node{Type: html.TextNode, Data: "\n\n"}, child)
+		}
+
+		child = next
+	}
+
+	// trim the spaces left over from the
+	// indentation of the markup
+	trimEdges(node)
+}
+
+// trimEdges trims the leading spaces of the first child of the provided HTML node,
+// and the trailing spaces of its last child, when they are text nodes - in place.
+func trimEdges(node *html.Node) {
+	if first := node.FirstChild; first != nil && first.Type == html.TextNode {
+		first.Data = strings.TrimLeft(first.Data, " ")
+	}
+
+	if last := node.LastChild; last != nil && last.Type == html.TextNode {
+		last.Data = strings.TrimRight(last.Data, " ")
+	}
+}
+
+// clearAttributes recursively clears out the attributes of a
+// provided HTML node - in place.
+func clearAttributes(node *html.Node) {
+	traverseNode(node, func(n *html.Node) {
+		n.Attr = []html.Attribute{}
+	})
+}
+
+// extractCommentAuthor extracts the author's name from the provided HTML node and
+// assigns it to the model.Comment struct. Returns nil if the author cannot be found.
+func (p *Parser) extractCommentAuthor(node *html.Node, comment *model.Comment) error {
+	ref := getChildRefByClass(node, p.classes.User)
+
+	if ref == nil || ref.FirstChild == nil {
+		return nil
+	}
+
+	comment.Author = cleanText(ref.FirstChild.Data)
+
+	return nil
+}
+
+// extractCommentDate extracts and parses the date of the comment from the provided
+// HTML node, along with the relative age text that HN renders (e.g. "3 hours ago"),
+// and assigns them to the model.Comment struct. Returns an error if the date cannot
+// be parsed.
+func (p *Parser) extractCommentDate(node *html.Node, comment *model.Comment) error {
+	ref := getChildRefByClass(node, p.classes.Age)
+
+	if ref == nil {
+		return nil
+	}
+
+	comment.AgeText = strings.TrimSpace(fixText(getText(ref)))
+
+	posted, timestamp, err := p.parseAge(getAttr(ref, "title"), comment.AgeText)
+
+	if err != nil {
+		comment.RawDate, err = p.tolerateDate(err)
+
+		return err
+	}
+
+	comment.Date = posted
+	comment.Timestamp = timestamp
+
+	return nil
+}
+
+// isPageSpace checks whether the provided HTML node represents a "pagespace" <tr> tag.
+// Returns true if the node has an ID attribute with the value "pagespace", false otherwise.
+func isPageSpace(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == "id" && attr.Val == "pagespace" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// extractTitle extracts the title and its reference URL, resolved against the base
+// URL and rewritten by the URL sanitizer, if any, from the provided HTML node and
+// assigns them to the model.Item struct. Returns an error if the title or URL
+// cannot be extracted or parsed.
+func (p *Parser) extractTitle(node *html.Node, item *model.Item) error {
+	// if you are new to Go, then you should know that
+	// Go really hates cyclomatic complexity and nested
+	// if statements.
+
+	if node == nil || node.Data != "td" {
+		return nil
+	}
+
+	hasTitleClass := classIs(node, p.classes.Title)
+
+	// if a title class doesn't even exist,
+	// then don't waste anymore time
+	if !hasTitleClass {
+		return nil
+	}
+
+	// the cell may hold text nodes before the span
+	spanChild := getFirstElementChild(node, "span")
+
+	if spanChild == nil {
+		return nil
+	}
+
+	hasTitleLine := classIs(spanChild, p.classes.TitleLine)
+
+	// if a titleline class doesn't exist,
+	// don't waste anymore time
+	if !hasTitleLine {
+		return nil
+	}
+
+	// the titleline may hold text nodes before the
+	// anchor, and a sitebit span after it
+	aChild := getFirstElementChild(spanChild, "a")
+
+	if aChild == nil {
+		p.logSkip("title", "titleline has no anchor")
+
+		return nil
+	}
+
+	// the anchor of a deleted story has no text, while
+	// that of others may nest inline formatting
+	if text := getText(aChild); text != "" {
+		item.Title.Name = cleanText(text)
+		item.Found.Title = true
+	}
+
+	// find the reference
+	href := getAttr(aChild, "href")
+
+	// self-posts link back to the item
+	// through a relative reference
+	reference, err := p.resolve(href)
+
+	if err != nil {
+		return err
+	}
+
+	item.Title.Reference = p.sanitize(reference)
+
+	return p.extractSecondaryLinks(spanChild, aChild, item)
+}
+
+// extractSecondaryLinks extracts the links of the provided titleline beyond its
+// provided main anchor, such as the demo that a Show HN links to alongside the
+// project, resolved against the base URL and rewritten by the URL sanitizer, if
+// any, and assigns them to the model.Item struct. The link to the site of the
+// sitebit is skipped. Returns an error if a URL cannot be parsed.
+func (p *Parser) extractSecondaryLinks(titleLine *html.Node, main *html.Node, item *model.Item) error {
+	var links []*url.URL
+
+	var err error
+
+	traverseNode(titleLine, func(n *html.Node) {
+		if err != nil || n == main || n.Type != html.ElementNode || n.Data != "a" || p.inSitebit(n, titleLine) {
+			return
+		}
+
+		var link *url.URL
+
+		if link, err = p.resolve(getAttr(n, "href")); err != nil {
+			return
+		}
+
+		if link = p.sanitize(link); link != nil {
+			links = append(links, link)
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	item.SecondaryLinks = links
+
+	return nil
+}
+
+// inSitebit checks whether the provided HTML node lies within the "sitebit" span
+// of the provided titleline. Returns true if one of its ancestors below the
+// titleline is a sitebit, false otherwise.
+func (p *Parser) inSitebit(node *html.Node, titleLine *html.Node) bool {
+	for parent := node.Parent; parent != nil && parent != titleLine; parent = parent.Parent {
+		if classIs(parent, p.classes.SiteBit) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// extractDomain extracts the site string displayed next to the title from the
+// provided HTML node and assigns it to the model.Item struct. Returns nil if the
+// domain cannot be found, as is the case for self-posts.
+func (p *Parser) extractDomain(node *html.Node, item *model.Item) error {
+	if node != nil && classIs(node, p.classes.Site) && node.FirstChild != nil {
+		item.Domain = cleanText(node.FirstChild.Data)
+	}
+
+	return nil
+}
+
+// extractRank extracts and parses the rank displayed before the title of an item
+// on a listing page (e.g. "1.") from the provided HTML node and assigns it to the
+// model.Item struct. The rank is empty on the page of the item itself, leaving the
+// item untouched. Returns a *ParseError if the rank cannot be parsed.
+func (p *Parser) extractRank(node *html.Node, item *model.Item) error {
+	if node == nil || node.Data != "span" || !classIs(node, p.classes.Rank) {
+		return nil
+	}
+
+	rankText := strings.TrimSuffix(strings.TrimSpace(getText(node)), ".")
+
+	if rankText == "" {
+		return nil
+	}
+
+	rank, err := strconv.Atoi(rankText)
+
+	if err != nil {
+		return &ParseError{Field: "rank", Raw: rankText, Err: err}
+	}
+
+	item.Rank = rank
+
+	return nil
+}
+
+// extractVoteable determines whether the provided "athing" row of the item carries
+// the upvote arrow of the item, and whether the arrow was hidden by a vote of the
+// viewer, and assigns the results to the model.Item struct. The arrow is missing
+// from job postings, and from pages saved by a viewer who cannot vote.
+func (p *Parser) extractVoteable(node *html.Node, item *model.Item) error {
+	if !p.isItemRow(node) {
+		return nil
+	}
+
+	arrow := getChildRefByID(node, "up_"+getAttr(node, "id"))
+
+	item.Voteable = arrow != nil
+
+	item.Upvoted = p.isHiddenArrow(arrow)
+
+	return nil
+}
+
+// extractFavicon extracts the URL of the favicon of the site of the item, which HN
+// renders beside the vote arrows in the provided "athing" row of the item, resolved
+// against the base URL, and assigns it to the model.Item struct. Items without a
+// favicon are left untouched. Returns a *ParseError if the URL cannot be parsed.
+func (p *Parser) extractFavicon(node *html.Node, item *model.Item) error {
+	if !p.isItemRow(node) {
+		return nil
+	}
+
+	imgNode := getChildRefByPredicate(node, p.isFavicon)
+
+	if imgNode == nil {
+		return nil
+	}
+
+	src := getAttr(imgNode, "src")
+
+	faviconURL, err := p.resolve(src)
+
+	if err != nil {
+		return &ParseError{Field: "favicon", Raw: src, Err: err}
+	}
+
+	item.FaviconURL = faviconURL
+
+	return nil
+}
+
+// isFavicon checks whether the provided HTML node is the favicon of the site of an
+// item, namely an image carrying the "votelinks" class or lying in the "votelinks"
+// cell of the item. The spacer image ("s.gif") that stands in for a missing vote
+// arrow is skipped, as are the images of the vote links, such as the "grayarrow.gif"
+// arrow of older markup.
+func (p *Parser) isFavicon(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.Data != "img" {
+		return false
+	}
+
+	src := getAttr(node, "src")
+
+	if src == "" || path.Base(src) == spacerImage {
+		return false
+	}
+
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if isVoteLink(parent) {
+			return false
+		}
+
+		if parent.Data == "td" && classIs(parent, p.classes.VoteLinks) {
+			return true
+		}
+	}
+
+	return classIs(node, p.classes.VoteLinks)
+}
+
+// isVoteLink checks whether the provided HTML node is the upvote or downvote link
+// of an item or comment, whose ID is that of the item prefixed with "up_" or "down_".
+func isVoteLink(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.Data != "a" {
+		return false
+	}
+
+	id := getAttr(node, "id")
+
+	return strings.HasPrefix(id, "up_") || strings.HasPrefix(id, "down_")
+}
+
+// isHiddenArrow checks whether the provided upvote arrow was hidden after the viewer
+// voted, which HN does either with an inline "visibility:hidden" style or with the
+// "nosee" class. Returns false for a nil arrow.
+func (p *Parser) isHiddenArrow(arrow *html.Node) bool {
+	if arrow == nil {
+		return false
+	}
+
+	if classIs(arrow, p.classes.Hidden) {
+		return true
+	}
+
+	style := strings.ReplaceAll(strings.ToLower(getAttr(arrow, "style")), " ", "")
+
+	return strings.Contains(style, "visibility:hidden")
+}
+
+// extractScore extracts and parses the score from the provided HTML node and assigns it
+// to the model.Item struct. Returns an error if the score cannot be parsed.
+func (p *Parser) extractScore(node *html.Node, item *model.Item) error {
+	if node == nil || node.Data != "span" {
+		return nil
+	}
+
+	hasScore := classIs(node, p.classes.Score)
+
+	if !hasScore {
+		return nil
+	}
+
+	if node.FirstChild == nil {
+		p.logSkip("score", "score span is empty")
+
+		return nil
+	}
+
+	// the options of a poll carry scores of their own,
+	// which are told apart by the ID of their span
+	if item.Found.ID && !isScoreOf(node, item.ID) {
+		return nil
+	}
+
+	points, err := parseScore(fixText(node.FirstChild.Data))
+
+	if err != nil {
+		return err
+	}
+
+	item.Points = points
+	item.Found.Score = true
+
+	return nil
+}
+
+// isScoreOf checks whether the provided "score" span belongs to the item with the
+// specified ID, as identified by its "score_<id>" ID. Spans lacking such an ID are
+// assumed to belong to the item.
+func isScoreOf(node *html.Node, id int) bool {
+	scoreID, ok := strings.CutPrefix(getAttr(node, "id"), "score_")
+
+	if !ok {
+		return true
+	}
+
+	return scoreID == strconv.Itoa(id)
+}
+
+// parseScore parses the integer of the provided score text, such as "194 points",
+// "1 point", or "1,204 points", regardless of the words around it. Returns a
+// *ParseError if the text holds no integer.
+func parseScore(scoreText string) (int, error) {
+	return parseNumber("score", scoreText)
+}
+
+// parseNumber parses the integer of the provided text of the specified field,
+// regardless of the words around it and of any thousands separators. Returns a
+// *ParseError for the field if the text holds no integer.
+func parseNumber(field string, text string) (int, error) {
+	match := numberRegex.FindString(text)
+
+	if match == "" {
+		return 0, &ParseError{Field: field, Raw: text, Err: errMalformed}
+	}
+
+	n, err := strconv.Atoi(strings.ReplaceAll(match, ",", ""))
+
+	if err != nil {
+		return 0, &ParseError{Field: field, Raw: text, Err: err}
+	}
+
+	return n, nil
+}
+
+// extractDate extracts and parses the date of the item from the provided HTML node,
+// along with the relative age text that HN renders (e.g. "3 hours ago"), and assigns
+// them to the model.Item struct. Returns an error if the date cannot be parsed.
+func (p *Parser) extractDate(node *html.Node, item *model.Item) error {
+	if node == nil || node.Data != "span" {
+		return nil
+	}
+
+	hasDate := classIs(node, p.classes.Age)
+
+	if !hasDate {
+		return nil
+	}
+
+	item.AgeText = strings.TrimSpace(fixText(getText(node)))
+
+	posted, timestamp, err := p.parseAge(getAttr(node, "title"), item.AgeText)
+
+	if err != nil {
+		item.RawDate, err = p.tolerateDate(err)
+
+		return err
 	}
 
+	item.Date = posted
+	item.Found.Date = true
+	item.Timestamp = timestamp
+
 	return nil
 }
 
-// extractComments traverses an HTML node tree to extract and parse comments within
-// a "comment-tree" structure, populating the provided model.Item with a list of
-// model.Comment structs. Returns an error if any issues arise during comment extraction.
-func extractComments(node *html.Node, item *model.Item) error {
-	if node == nil || node.FirstChild == nil || !classIs(node, "comment-tree") {
-		return nil
+// parseAge parses the date of an "age" node from its title attribute, falling back
+// to its age text when the attribute is missing or empty, as is the case on some
+// incomplete pages. The age text yields an approximate date, relative to the current
+// time of the Parser for recent ages (e.g. "5 hours ago"). Returns a *ParseError if
+// neither form can be parsed.
+func (p *Parser) parseAge(title, ageText string) (time.Time, int64, error) {
+	if strings.TrimSpace(title) != "" || ageText == "" {
+		return parseTimestamp(title, p.location)
 	}
 
-	var comments []model.Comment
-
-	commentChild := getChildRefByClass(node, "athing comtr")
+	posted, err := parseAgeText(ageText, p.now().In(p.location))
 
-	if commentChild == nil {
-		return nil
+	if err != nil {
+		return time.Time{}, 0, &ParseError{Field: "date", Raw: ageText, Err: err}
 	}
 
-	// make sure we are scanned to the exact one
-	for commentChild.PrevSibling != nil {
-		commentChild = commentChild.PrevSibling
-	}
+	return posted, posted.Unix(), nil
+}
 
-	for child := commentChild; child != nil; child = child.NextSibling {
-		comment, err := extractComment(child)
+// tolerateDate handles the provided error of parsing a date. A Parser configured
+// with lenient dates tolerates a *ParseError, returning the raw date that failed
+// to parse to be recorded in its stead, and counting it in the Stats, if any. Any
+// other error is returned as is.
+func (p *Parser) tolerateDate(err error) (string, error) {
+	var parseErr *ParseError
 
-		if err != nil {
-			return err
-		}
+	if !p.lenientDates || !errors.As(err, &parseErr) {
+		return "", err
+	}
 
-		if comment != nil {
-			comments = append(comments, *comment)
-		}
+	if p.stats != nil {
+		p.stats.recordError(err)
 	}
 
-	item.Comments = comments
+	p.logSkip(parseErr.Field, fmt.Sprintf("tolerated unparsable date %q", parseErr.Raw))
 
-	return nil
+	return parseErr.Raw, nil
 }
 
-// extractComment extracts and parses a single comment from an HTML node, populating
-// a model.Comment struct with the relevant data such as ID, author, date, parent ID,
-// and content. Returns a pointer to the populated model.Comment and an error if any
-// issues occur during the parsing process.
-func extractComment(node *html.Node) (*model.Comment, error) {
-	var comment model.Comment
+// parseAgeText parses the age text that HN renders, either relative to the provided
+// time (e.g. "5 hours ago") or as a date (e.g. "on Oct 3, 2011"), which is interpreted
+// in the location of the provided time. Returns an error if the text has neither form.
+func parseAgeText(ageText string, now time.Time) (time.Time, error) {
+	match := relativeAgeRegex.FindStringSubmatch(ageText)
 
-	if node == nil || !classIs(node, "athing comtr") {
-		return nil, nil
+	if match == nil {
+		return time.ParseInLocation(absoluteAgeLayout, ageText, now.Location())
 	}
 
-	if err := extractCommentID(node, &comment); err != nil {
-		return nil, err
-	}
+	n, err := strconv.Atoi(match[1])
 
-	// scan to here to improve efficiency
-	defaultNode := getChildRefByClass(node, "default")
+	if err != nil {
+		return time.Time{}, err
+	}
 
-	if defaultNode == nil {
-		return nil, nil
+	switch match[2] {
+	case "second":
+		return now.Add(-time.Duration(n) * time.Second), nil
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute), nil
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case "day":
+		return now.AddDate(0, 0, -n), nil
+	case "month":
+		return now.AddDate(0, -n, 0), nil
+	default:
+		return now.AddDate(-n, 0, 0), nil
 	}
+}
 
-	if err := extractCommentAuthor(node, &comment); err != nil {
-		return nil, err
+// parseTimestamp parses the title attribute of an "age" node, which holds the date
+// and optionally the Unix timestamp (e.g. "2011-10-03T18:32:05 1317666725"). The
+// date is parsed by parseHNDate, and interpreted in the provided location unless
+// it carries a UTC offset. When present, the timestamp is
+// authoritative and the date is derived from it; otherwise the timestamp is derived
+// from the date. Returns an error if either the date or the timestamp cannot be parsed.
+func parseTimestamp(title string, loc *time.Location) (time.Time, int64, error) {
+	fields := strings.Fields(title)
+
+	if len(fields) == 0 {
+		fields = []string{title}
 	}
 
-	if err := extractCommentDate(node, &comment); err != nil {
-		return nil, err
+	posted, err := parseHNDate(fields[0], loc)
+
+	if err != nil {
+		return time.Time{}, 0, &ParseError{Field: "date", Raw: title, Err: err}
 	}
 
-	if err := extractParentID(node, &comment); err != nil {
-		return nil, err
+	if len(fields) < 2 {
+		return posted, posted.Unix(), nil
 	}
 
-	if err := extractContent(node, &comment); err != nil {
-		return nil, err
+	timestamp, err := strconv.ParseInt(fields[1], 10, 64)
+
+	if err != nil {
+		return time.Time{}, 0, &ParseError{Field: "timestamp", Raw: title, Err: err}
 	}
 
-	return &comment, nil
+	return time.Unix(timestamp, 0).In(loc), timestamp, nil
 }
 
-// extractCommentID extracts the comment ID from the provided HTML node and assigns it
-// to the model.Comment struct. Returns an error if the ID cannot be parsed.
-func extractCommentID(node *html.Node, comment *model.Comment) error {
-	if node == nil || !classIs(node, "athing comtr") {
-		return nil
-	}
+// parseHNDate parses the provided date with each of the dateLayouts in turn, and
+// returns the date of the first layout that matches. Dates without a UTC offset
+// are interpreted in the provided location. Returns the error of the first layout
+// if none of them matches.
+func parseHNDate(date string, loc *time.Location) (time.Time, error) {
+	var firstErr error
 
-	idString := getAttr(node, "id")
+	for _, layout := range dateLayouts {
+		posted, err := time.ParseInLocation(layout, date, loc)
 
-	id, err := strconv.Atoi(idString)
+		if err == nil {
+			return posted, nil
+		}
 
-	if err != nil {
-		return err
+		if firstErr == nil {
+			firstErr = err
+		}
 	}
 
-	comment.ID = id
+	return time.Time{}, firstErr
+}
+
+// extractAuthor extracts the author's name from the provided HTML node and assigns it
+// to the model.Item struct. Returns nil if the author cannot be found.
+func (p *Parser) extractAuthor(node *html.Node, item *model.Item) error {
+	if node != nil && classIs(node, p.classes.User) && node.FirstChild != nil {
+		author := cleanText(node.FirstChild.Data)
+
+		item.Author = author
+		item.Found.Author = true
+	}
 
 	return nil
 }
 
-// extractParentID extracts the parent ID of a comment, if it exists.
-func extractParentID(node *html.Node, comment *model.Comment) error {
-	parentNode := getChildRefByData(node, "parent")
+// extractCommentCount extracts and parses the number of comments from the
+// comments link of the subline (e.g. "118 comments") and assigns it to the
+// model.Item struct. The "discuss" link of an item without comments yields 0.
+// Only the links held by the subline of the Parser's class names are considered,
+// leaving out the link to the author, whose name could read "discuss". Returns a
+// *ParseError if the count cannot be parsed.
+func (p *Parser) extractCommentCount(node *html.Node, item *model.Item) error {
+	if node == nil || node.Data != "a" || node.FirstChild == nil {
+		return nil
+	}
 
-	if parentNode == nil {
+	if !classIs(node.Parent, p.classes.Subline) || classIs(node, p.classes.User) {
 		return nil
 	}
 
-	parent := parentNode.Parent
+	// the text is separated by a non-breaking space, which
+	// strings.Fields (unlike fixText) treats as whitespace
+	fields := strings.Fields(node.FirstChild.Data)
 
-	ref := getAttr(parent, "href")
+	if len(fields) == 1 && fields[0] == "discuss" {
+		item.CommentCount = 0
 
-	if ref == "" {
 		return nil
 	}
 
-	pid, err := strconv.Atoi(ref[1:])
+	if len(fields) != 2 || (fields[1] != "comments" && fields[1] != "comment") {
+		return nil
+	}
+
+	count, err := parseNumber("comment count", fields[0])
 
 	if err != nil {
 		return err
 	}
 
-	comment.ParentID = &pid
+	item.CommentCount = count
 
 	return nil
 }
 
-// extractContent extracts the content of a comment from the provided HTML node and
-// assigns it to the model.Comment struct. Returns an error if content extraction fails.
-func extractContent(node *html.Node, comment *model.Comment) error {
-	contentNode := getChildRefByClass(node, "commtext c00")
+// checkSubline notifies the logger of the Parser of each of the score, author,
+// and date that the provided "subline" HTML node of the item lacks, as their
+// extractors skip them silently. The sublines of poll options, which hold a
+// score alone, are left alone.
+func (p *Parser) checkSubline(node *html.Node, item *model.Item) {
+	if p.logger == nil {
+		return
+	}
 
-	if contentNode == nil {
-		return nil
+	if score := getChildRefByClass(node, p.classes.Score); score != nil && item.Found.ID && !isScoreOf(score, item.ID) {
+		return
 	}
 
-	var buf bytes.Buffer
+	expected := []struct {
+		field string
+		class string
+	}{
+		{field: "score", class: p.classes.Score},
+		{field: "author", class: p.classes.User},
+		{field: "date", class: p.classes.Age},
+	}
 
-	contentCopy := *contentNode
+	for _, e := range expected {
+		if getChildRefByClass(node, e.class) == nil {
+			p.logSkip(e.field, fmt.Sprintf("subline has no %q node", e.class))
+		}
+	}
+}
 
-	clearAttributes(&contentCopy)
+// logSkip notifies the logger of the Parser, if any, that the extraction of
+// the provided field was skipped for the provided reason.
+func (p *Parser) logSkip(field string, reason string) {
+	if p.logger != nil {
+		p.logger(field, reason)
+	}
+}
 
-	err := html.Render(&buf, &contentCopy)
+// extractRelatedDiscussions extracts the links to past discussions of the item
+// from the provided "subline" HTML node, resolves them against the base URL, and
+// assigns them to the model.Item struct. The standard links of the subline, namely
+// those to the author, the item itself, the search of past submissions, and the
+// actions on the item (e.g. hide or flag), are filtered out. Returns a *ParseError
+// if a link cannot be parsed.
+func (p *Parser) extractRelatedDiscussions(node *html.Node, item *model.Item) error {
+	var related []*url.URL
 
-	if err != nil {
-		return err
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "a" {
+			continue
+		}
+
+		if classIs(child, p.classes.User) || classIs(child, p.classes.PastLink) {
+			continue
+		}
+
+		href := getAttr(child, "href")
+
+		if href == "" || isSublineAction(href) {
+			continue
+		}
+
+		// the comments link points back at the item
+		if id, err := parseIDParam(href); err == nil && id == item.ID {
+			continue
+		}
+
+		link, err := p.resolve(href)
+
+		if err != nil {
+			return &ParseError{Field: "related", Raw: href, Err: err}
+		}
+
+		related = append(related, link)
 	}
 
-	comment.Content = fixText(buf.String())
+	item.RelatedDiscussions = related
 
 	return nil
 }
 
-// clearAttributes recursively clears out the attributes of a
-// provided HTML node - in place.
-func clearAttributes(node *html.Node) {
-	traverseNode(node, func(n *html.Node) {
-		n.Attr = []html.Attribute{}
+// extractActionLinks extracts the links that hide, flag, edit, and delete the item
+// from the provided "subtext" HTML node, which holds them within the subline of a
+// story or directly for a job posting, and assigns them to the model.Item struct.
+// The edit and delete links are only displayed to the author of the item. Returns
+// an error if a link cannot be resolved.
+func (p *Parser) extractActionLinks(node *html.Node, item *model.Item) error {
+	return p.resolveActionLinks(node, map[string]**url.URL{
+		"hide?":           &item.HideURL,
+		"flag?":           &item.FlagURL,
+		"edit?":           &item.EditURL,
+		"delete-confirm?": &item.DeleteURL,
 	})
 }
 
-// extractCommentAuthor extracts the author's name from the provided HTML node and
-// assigns it to the model.Comment struct. Returns nil if the author cannot be found.
-func extractCommentAuthor(node *html.Node, comment *model.Comment) error {
-	ref := getChildRefByClass(node, "hnuser")
+// extractCommentActionLinks extracts the links that edit and delete a comment from
+// its header, which are only displayed to the author of the comment, and assigns
+// them to the model.Comment struct. Returns an error if a link cannot be resolved.
+func (p *Parser) extractCommentActionLinks(node *html.Node, comment *model.Comment) error {
+	headNode := getChildRefByClass(node, p.classes.CommentHead)
 
-	if ref == nil || ref.FirstChild == nil {
+	if headNode == nil {
 		return nil
 	}
 
-	comment.Author = ref.FirstChild.Data
-
-	return nil
+	return p.resolveActionLinks(headNode, map[string]**url.URL{
+		"edit?":           &comment.EditURL,
+		"delete-confirm?": &comment.DeleteURL,
+	})
 }
 
-// extractCommentDate extracts and parses the date of the comment from the provided
-// HTML node and assigns it to the model.Comment struct. Returns an error if the
-// date cannot be parsed.
-func extractCommentDate(node *html.Node, comment *model.Comment) error {
-	ref := getChildRefByClass(node, "age")
+// resolveActionLinks resolves the first link beneath the provided HTML node for
+// each of the specified action prefixes (such as "hide?") against the base URL,
+// and assigns it to the target of the action. Links are told apart by their
+// action, so that other links, such as the comments link, are never mistaken for
+// one of them. Returns a *ParseError if a link cannot be resolved.
+func (p *Parser) resolveActionLinks(node *html.Node, targets map[string]**url.URL) error {
+	var err error
 
-	if ref == nil {
-		return nil
-	}
+	traverseNode(node, func(n *html.Node) {
+		if err != nil || n.Type != html.ElementNode || n.Data != "a" {
+			return
+		}
 
-	titleString := getAttr(ref, "title")
+		href := getAttr(n, "href")
 
-	posted, err := time.Parse(dateLayout, titleString)
+		for action, target := range targets {
+			if !strings.HasPrefix(href, action) || *target != nil {
+				continue
+			}
 
-	if err != nil {
-		return err
-	}
+			link, resolveErr := p.resolve(href)
 
-	comment.Date = posted
+			if resolveErr != nil {
+				err = &ParseError{Field: "action link", Raw: href, Err: resolveErr}
 
-	return nil
+				return
+			}
+
+			*target = link
+		}
+	})
+
+	return err
 }
 
-// isPageSpace checks whether the provided HTML node represents a "pagespace" <tr> tag.
-// Returns true if the node has an ID attribute with the value "pagespace", false otherwise.
-func isPageSpace(node *html.Node) bool {
-	for _, attr := range node.Attr {
-		if attr.Key == "id" && attr.Val == "pagespace" {
+// isSublineAction checks whether the provided reference is one of the standard
+// links that HN displays in the subline of an item, such as the one to hide it.
+func isSublineAction(ref string) bool {
+	for _, action := range sublineActions {
+		if strings.HasPrefix(ref, action) {
 			return true
 		}
 	}
@@ -349,162 +2407,212 @@ func isPageSpace(node *html.Node) bool {
 	return false
 }
 
-// extractTitle extracts the title and its reference URL from the provided HTML node
-// and assigns them to the model.Item struct. Returns an error if the title or URL
-// cannot be extracted or parsed.
-func extractTitle(node *html.Node, item *model.Item) error {
-	// if you are new to Go, then you should know that
-	// Go really hates cyclomatic complexity and nested
-	// if statements.
-
-	if node == nil || node.Data != "td" {
+// extractJob determines whether the provided "subtext" HTML node belongs to a job
+// posting, which carries neither a score nor an author, and assigns the result to
+// the model.Item struct.
+func (p *Parser) extractJob(node *html.Node, item *model.Item) error {
+	if node == nil || !classIs(node, p.classes.Subtext) {
 		return nil
 	}
 
-	hasTitleClass := getAttr(node, "class") == "title"
+	item.IsJob = !hasChildClass(node, p.classes.Score) && !hasChildClass(node, p.classes.User)
 
-	// if a title class doesn't even exist,
-	// then don't waste anymore time
-	if !hasTitleClass {
-		return nil
-	}
+	return nil
+}
 
-	spanChild := node.FirstChild
+// extractPollOption extracts the text of a poll option from the provided "pollopt"
+// HTML node and its score from the row that follows it, and appends the option to
+// the poll of the model.Item struct. Returns an error if the score cannot be parsed.
+func (p *Parser) extractPollOption(node *html.Node, item *model.Item) error {
+	textNode := p.getCommentTextNode(node)
 
-	if spanChild == nil || spanChild.Data != "span" {
+	if textNode == nil {
 		return nil
 	}
 
-	hasTitleLine := getAttr(spanChild, "class") == "titleline"
+	option := model.PollOption{Text: cleanText(getText(textNode))}
 
-	// if a titleline class doesn't exist,
-	// don't waste anymore time
-	if !hasTitleLine {
-		return nil
-	}
-
-	aChild := spanChild.FirstChild
+	scoreRow := node.NextSibling
 
-	if aChild == nil || aChild.Data != "a" {
-		return nil
+	for scoreRow != nil && scoreRow.Type != html.ElementNode {
+		scoreRow = scoreRow.NextSibling
 	}
 
-	item.Title.Name = fixText(aChild.FirstChild.Data)
+	if scoreNode := getChildRefByClass(scoreRow, p.classes.Score); scoreNode != nil && scoreNode.FirstChild != nil {
+		points, err := parseScore(fixText(scoreNode.FirstChild.Data))
 
-	// find the reference
-	href := getAttr(aChild, "href")
+		if err != nil {
+			return err
+		}
 
-	reference, err := url.Parse(href)
+		option.Points = points
+	}
 
-	if err != nil {
-		return err
+	if item.Poll == nil {
+		item.Poll = &model.Poll{}
 	}
 
-	item.Title.Reference = reference
+	item.Poll.Options = append(item.Poll.Options, option)
 
 	return nil
 }
 
-// extractScore extracts and parses the score from the provided HTML node and assigns it
-// to the model.Item struct. Returns an error if the score cannot be parsed.
-func extractScore(node *html.Node, item *model.Item) error {
-	if node == nil || node.Data != "span" {
+// extractNextPage extracts the URL of the next page of comments from the provided
+// "morelink" HTML node, resolves it against the base URL, and assigns it to the
+// model.Item struct. Returns an error if the URL cannot be parsed.
+func (p *Parser) extractNextPage(node *html.Node, item *model.Item) error {
+	if node == nil || node.Data != "a" || !classIs(node, p.classes.MoreLink) {
 		return nil
 	}
 
-	hasScore := getAttr(node, "class") == "score"
+	href := getAttr(node, "href")
 
-	if !hasScore {
+	if href == "" {
 		return nil
 	}
 
-	if node.FirstChild == nil {
-		return nil
-	}
+	nextPage, err := p.resolve(href)
 
-	scoreText := fixText(node.FirstChild.Data)
+	if err != nil {
+		return err
+	}
 
-	scoreSlice := strings.Split(scoreText, " ")
+	item.NextPage = nextPage
 
-	if len(scoreSlice) != 2 {
-		return nil
-	}
+	return nil
+}
 
-	points, err := strconv.Atoi(scoreSlice[0])
+// resolve parses the provided reference and resolves it against the base URL of
+// the Parser, if any. Returns an error if the reference cannot be parsed.
+func (p *Parser) resolve(ref string) (*url.URL, error) {
+	parsed, err := url.Parse(ref)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	item.Points = points
+	if p.base == nil {
+		return parsed, nil
+	}
 
-	return nil
+	return p.base.ResolveReference(parsed), nil
 }
 
-// extractDate extracts and parses the date of the item from the provided HTML node
-// and assigns it to the model.Item struct. Returns an error if the date cannot be parsed.
-func extractDate(node *html.Node, item *model.Item) error {
-	if node == nil || node.Data != "span" {
+// extractOpenGraph extracts the Open Graph description and image from the <head>
+// of the provided document, which archived or proxied pages may carry, and assigns
+// them to the model.Item struct. Pages served by HN carry no such metadata, leaving
+// the item untouched. Returns an error if the image URL cannot be parsed.
+func (p *Parser) extractOpenGraph(node *html.Node, item *model.Item) error {
+	headNode := getChildRefByData(node, "head")
+
+	if headNode == nil {
 		return nil
 	}
 
-	hasDate := classIs(node, "age")
+	for child := headNode.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "meta" {
+			continue
+		}
 
-	if !hasDate {
-		return nil
-	}
+		content := getAttr(child, "content")
 
-	titleString := getAttr(node, "title")
+		switch getAttr(child, "property") {
+		case "og:description":
+			item.Description = cleanText(content)
+		case "og:image":
+			if content == "" {
+				continue
+			}
 
-	posted, err := time.Parse(dateLayout, titleString)
+			imageURL, err := p.resolve(content)
 
-	if err != nil {
-		return err
-	}
+			if err != nil {
+				return err
+			}
 
-	item.Date = posted
+			item.ImageURL = imageURL
+		}
+	}
 
 	return nil
 }
 
-// extractAuthor extracts the author's name from the provided HTML node and assigns it
-// to the model.Item struct. Returns nil if the author cannot be found.
-func extractAuthor(node *html.Node, item *model.Item) error {
-	if node != nil && classIs(node, "hnuser") && node.FirstChild != nil {
-		author := fixText(node.FirstChild.Data)
+// extractPageTitle extracts the text of the <title> in the <head> of the provided
+// document, less the " | Hacker News" suffix, and assigns it to the model.Item
+// struct. It serves as a fallback for the name of the item should the markup of
+// the title change.
+func extractPageTitle(node *html.Node, item *model.Item) {
+	headNode := getChildRefByData(node, "head")
 
-		item.Author = author
+	if headNode == nil {
+		return
 	}
 
-	return nil
+	titleNode := getFirstElementChild(headNode, "title")
+
+	if titleNode == nil {
+		return
+	}
+
+	title := cleanText(getText(titleNode))
+
+	item.PageTitle = strings.TrimSpace(strings.TrimSuffix(title, pageTitleSuffix))
 }
 
 // extractID extracts and parses the ID of the item from the provided HTML node and
 // assigns it to the model.Item struct. Returns an error if the ID cannot be parsed.
-func extractID(node *html.Node, item *model.Item) error {
-	if node != nil && classIs(node, "athing") && node.FirstChild != nil {
+func (p *Parser) extractID(node *html.Node, item *model.Item) error {
+	if p.isItemRow(node) && node.FirstChild != nil {
 		idString := getAttr(node, "id")
 
 		id, err := strconv.Atoi(idString)
 
 		if err != nil {
-			return err
+			return &ParseError{Field: "id", Raw: idString, Err: err}
 		}
 
 		item.ID = id
+		item.Found.ID = true
 	}
 
 	return nil
 }
 
+// parseIDParam parses the ID held by the "id" query parameter of the provided
+// reference, such as "item?id=3067403". Returns a *ParseError if the reference
+// or the ID cannot be parsed.
+func parseIDParam(ref string) (int, error) {
+	parsed, err := url.Parse(ref)
+
+	if err != nil {
+		return 0, &ParseError{Field: "id", Raw: ref, Err: err}
+	}
+
+	id, err := strconv.Atoi(parsed.Query().Get("id"))
+
+	if err != nil {
+		return 0, &ParseError{Field: "id", Raw: ref, Err: err}
+	}
+
+	return id, nil
+}
+
 // fixText removes any extraneous whitespace from the provided text string to ensure
 // the text is clean and free of unnecessary spaces. Returns the cleaned text string.
 func fixText(text string) string {
-	regex := regexp.MustCompile(`\s+`)
-	strs := regex.Split(text, -1)
+	strs := whitespaceRegex.Split(text, -1)
 	return strings.Join(strs, " ")
 }
 
+// cleanText unescapes any HTML entities left in the provided text, such as those
+// of a title that was escaped twice (e.g. "Node&#39;s event loop"), and removes any
+// extraneous whitespace with fixText. It must only be used for plain text, never
+// for rendered HTML, whose escaped markup would otherwise become live markup.
+// Returns the cleaned text string.
+func cleanText(text string) string {
+	return fixText(html.UnescapeString(text))
+}
+
 // getAttr retrieves the value of the specified attribute from the provided HTML node.
 // Returns the attribute value as a string, or an empty string if the attribute is not found.
 func getAttr(node *html.Node, attr string) string {
@@ -517,12 +2625,61 @@ func getAttr(node *html.Node, attr string) string {
 	return ""
 }
 
+// getText concatenates the data of all of the text nodes beneath the
+// provided HTML node, in document order. Returns an empty string if the
+// node has no text.
+func getText(node *html.Node) string {
+	var builder strings.Builder
+
+	traverseNode(node, func(n *html.Node) {
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+		}
+	})
+
+	return builder.String()
+}
+
 // hasChildClass checks whether the provided HTML node has a child node with the
 // specified class. Returns true if a matching child node is found, false otherwise.
 func hasChildClass(node *html.Node, class string) bool {
 	return getChildRefByClass(node, class) != nil
 }
 
+// getCommentTextNode returns the "commtext" node beneath the provided HTML node,
+// regardless of its modifier classes. Returns nil if no such node is found.
+func (p *Parser) getCommentTextNode(node *html.Node) *html.Node {
+	return getChildRefByClass(node, p.classes.CommentText)
+}
+
+// isCommentRow checks whether the provided HTML node is the row of a comment,
+// whose class holds "athing" and "comtr" along with any modifiers (such as the
+// "coll" and "noshow" classes of collapsed subtrees).
+func (p *Parser) isCommentRow(node *html.Node) bool {
+	return classIs(node, p.classes.Item+" "+p.classes.Comment)
+}
+
+// isItemRow checks whether the provided HTML node is the "athing" row of an item,
+// as opposed to the rows of comments and poll options, which carry the "athing"
+// class along with a class of their own.
+func (p *Parser) isItemRow(node *html.Node) bool {
+	return node != nil && node.Data == "tr" && classIs(node, p.classes.Item) &&
+		!classIs(node, p.classes.Comment) && !classIs(node, p.classes.PollOption)
+}
+
+// getFirstElementChild returns the first direct child of the provided HTML node
+// that is an element of the specified type, skipping over any other children.
+// Returns nil if no matching child node is found.
+func getFirstElementChild(node *html.Node, data string) *html.Node {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == data {
+			return child
+		}
+	}
+
+	return nil
+}
+
 // getChildRefByClass recursively searches for and returns the first child node
 // of the provided HTML node that matches the specified class. Returns nil if no
 // matching child node is found.
@@ -537,7 +2694,7 @@ func getChildRefByClass(node *html.Node, class string) *html.Node {
 // matching child node is found.
 func getChildRefByID(node *html.Node, id string) *html.Node {
 	return getChildRefByPredicate(node, func(n *html.Node) bool {
-		return getAttr(node, "id") == id
+		return getAttr(n, "id") == id
 	})
 }
 
@@ -550,21 +2707,30 @@ func getChildRefByData(node *html.Node, data string) *html.Node {
 	})
 }
 
-// getChildRefByData recursively searches for and returns the first child node
-// of the provided HTML node that matches the specified predicate. Returns nil if no
-// matching child node is found.
+// getChildRefByPredicate searches for and returns the first node, in pre-order,
+// of the provided HTML node and its descendants that matches the specified
+// predicate. The search uses an explicit stack rather than recursion, so deeply
+// nested documents do not grow the goroutine stack. Returns nil if no matching
+// node is found.
 func getChildRefByPredicate(node *html.Node, predicate func(*html.Node) bool) *html.Node {
 	if node == nil {
 		return nil
 	}
 
-	if predicate(node) {
-		return node
-	}
+	stack := []*html.Node{node}
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		if result := getChildRefByPredicate(child, predicate); result != nil {
-			return result
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if predicate(current) {
+			return current
+		}
+
+		// push the children in reverse, so that
+		// the first child is popped first
+		for child := current.LastChild; child != nil; child = child.PrevSibling {
+			stack = append(stack, child)
 		}
 	}
 
@@ -585,12 +2751,29 @@ func traverseNode(node *html.Node, fn func(*html.Node)) {
 	}
 }
 
-// classIs checks whether the provided HTML node belongs to the specified class.
-// Returns true if the node's class matches the specified class, false otherwise.
+// classIs checks whether the provided HTML node belongs to the specified class,
+// regardless of any other classes it carries. The class may list several names
+// separated by whitespace (such as "athing comtr"), in which case the node must
+// carry every one of them, in any order. Returns false for a nil node or an empty
+// class.
 func classIs(node *html.Node, class string) bool {
 	if node == nil {
 		return false
 	}
 
-	return getAttr(node, "class") == class
+	wanted := strings.Fields(class)
+
+	if len(wanted) == 0 {
+		return false
+	}
+
+	classes := strings.Fields(getAttr(node, "class"))
+
+	for _, name := range wanted {
+		if !slices.Contains(classes, name) {
+			return false
+		}
+	}
+
+	return true
 }
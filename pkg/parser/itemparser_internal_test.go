@@ -0,0 +1,359 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+// getChildRefByPredicateRecursive is the former, recursive implementation
+// of getChildRefByPredicate, kept as a reference for tests and benchmarks.
+func getChildRefByPredicateRecursive(node *html.Node, predicate func(*html.Node) bool) *html.Node {
+	if node == nil {
+		return nil
+	}
+
+	if predicate(node) {
+		return node
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if result := getChildRefByPredicateRecursive(child, predicate); result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// deeplyNestedNode builds a chain of the provided depth of nested
+// elements, each with a leading sibling, and returns its root.
+func deeplyNestedNode(depth int) *html.Node {
+	root := &html.Node{Type: html.ElementNode, Data: "div"}
+
+	current := root
+
+	for i := 0; i < depth; i++ {
+		current.AppendChild(&html.Node{Type: html.ElementNode, Data: "span"})
+
+		child := &html.Node{Type: html.ElementNode, Data: "div"}
+
+		current.AppendChild(child)
+
+		current = child
+	}
+
+	current.Attr = []html.Attribute{{Key: "class", Val: "leaf"}}
+
+	return root
+}
+
+// TestGetChildRefByPredicate tests that the iterative search finds
+// the same node as the recursive one for every class in a sample.
+func TestGetChildRefByPredicate(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	root, err := html.Parse(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	classes := make(map[string]struct{})
+
+	traverseNode(root, func(n *html.Node) {
+		classes[getAttr(n, "class")] = struct{}{}
+	})
+
+	for class := range classes {
+		predicate := func(n *html.Node) bool {
+			return classIs(n, class)
+		}
+
+		assert.Same(t, getChildRefByPredicateRecursive(root, predicate), getChildRefByPredicate(root, predicate))
+	}
+
+	assert.Nil(t, getChildRefByPredicate(root, func(*html.Node) bool { return false }))
+
+	assert.Nil(t, getChildRefByPredicate(nil, func(*html.Node) bool { return true }))
+
+	deep := deeplyNestedNode(1000)
+
+	assert.Same(t, getChildRefByPredicateRecursive(deep, isLeaf), getChildRefByPredicate(deep, isLeaf))
+}
+
+// isLeaf matches the innermost element of deeplyNestedNode.
+func isLeaf(n *html.Node) bool {
+	return classIs(n, "leaf")
+}
+
+// BenchmarkGetChildRefByPredicate benchmarks the iterative search
+// on a deeply nested document.
+func BenchmarkGetChildRefByPredicate(b *testing.B) {
+	deep := deeplyNestedNode(10000)
+
+	b.ReportAllocs()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		getChildRefByPredicate(deep, isLeaf)
+	}
+}
+
+// BenchmarkGetChildRefByPredicateRecursive benchmarks the recursive
+// search on a deeply nested document.
+func BenchmarkGetChildRefByPredicateRecursive(b *testing.B) {
+	deep := deeplyNestedNode(10000)
+
+	b.ReportAllocs()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		getChildRefByPredicateRecursive(deep, isLeaf)
+	}
+}
+
+// TestGetChildRefByID tests that nested elements are located by
+// their ID, rather than by the ID of the root.
+func TestGetChildRefByID(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	root, err := html.Parse(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	scoreNode := getChildRefByID(root, "score_3067403")
+
+	if assert.NotNil(t, scoreNode) {
+		assert.Equal(t, "score", getAttr(scoreNode, "class"))
+	}
+
+	commentNode := getChildRefByID(root, "3067519")
+
+	if assert.NotNil(t, commentNode) {
+		assert.Equal(t, "tr", commentNode.Data)
+	}
+
+	assert.Nil(t, getChildRefByID(root, "missing"))
+}
+
+// TestClassIs tests that nodes are matched by their classes
+// regardless of any modifier classes and of their order.
+func TestClassIs(t *testing.T) {
+	tests := []struct {
+		Testname string
+		Attr     string
+		Class    string
+		Expected bool
+	}{
+		{Testname: "TestSingleClass", Attr: "comment", Class: "comment", Expected: true},
+		{Testname: "TestModifierClass", Attr: "comment deleted", Class: "comment", Expected: true},
+		{Testname: "TestExtraWhitespace", Attr: "  comment\tdeleted ", Class: "deleted", Expected: true},
+		{Testname: "TestMultipleClasses", Attr: "athing comtr", Class: "athing comtr", Expected: true},
+		{Testname: "TestReorderedClasses", Attr: "comtr noshow athing", Class: "athing comtr", Expected: true},
+		{Testname: "TestMissingClass", Attr: "athing", Class: "athing comtr", Expected: false},
+		{Testname: "TestPrefixClass", Attr: "commtext c00", Class: "comm", Expected: false},
+		{Testname: "TestDifferentClass", Attr: "sitebit comhead", Class: "subline", Expected: false},
+		{Testname: "TestEmptyAttr", Attr: "", Class: "comment", Expected: false},
+		{Testname: "TestEmptyClass", Attr: "comment", Class: "", Expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			node := &html.Node{
+				Type: html.ElementNode,
+				Data: "div",
+				Attr: []html.Attribute{{Key: "class", Val: test.Attr}},
+			}
+
+			assert.Equal(t, test.Expected, classIs(node, test.Class))
+		})
+	}
+
+	assert.False(t, classIs(nil, "comment"))
+}
+
+// TestIsItemRow tests that the rows of comments and poll options
+// are not mistaken for the row of an item, now that their classes
+// are matched by membership.
+func TestIsItemRow(t *testing.T) {
+	p := New()
+
+	tests := []struct {
+		Testname string
+		Attr     string
+		Expected bool
+	}{
+		{Testname: "TestItemRow", Attr: "athing", Expected: true},
+		{Testname: "TestModifiedItemRow", Attr: "athing submission", Expected: true},
+		{Testname: "TestCommentRow", Attr: "athing comtr", Expected: false},
+		{Testname: "TestCollapsedCommentRow", Attr: "athing comtr coll", Expected: false},
+		{Testname: "TestPollOptionRow", Attr: "athing pollopt", Expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			node := &html.Node{
+				Type: html.ElementNode,
+				Data: "tr",
+				Attr: []html.Attribute{{Key: "class", Val: test.Attr}},
+			}
+
+			assert.Equal(t, test.Expected, p.isItemRow(node))
+		})
+	}
+}
+
+// TestParseHNDate tests that dates are parsed with each of the
+// supported layouts, and that dates without a UTC offset are
+// interpreted in the provided location.
+func TestParseHNDate(t *testing.T) {
+	pdt := time.FixedZone("PDT", -7*60*60)
+
+	tests := []struct {
+		Date     string
+		Location *time.Location
+		Expected time.Time
+		Fails    bool
+		Testname string
+	}{
+		{
+			Date:     "2011-10-03T18:32:05",
+			Location: time.UTC,
+			Expected: time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC),
+			Testname: "TestISO",
+		},
+		{
+			Date:     "2011-10-03T18:32:05",
+			Location: pdt,
+			Expected: time.Date(2011, time.October, 3, 18, 32, 5, 0, pdt),
+			Testname: "TestISOInLocation",
+		},
+		{
+			Date:     "2011-10-03T18:32:05.250",
+			Location: time.UTC,
+			Expected: time.Date(2011, time.October, 3, 18, 32, 5, 250000000, time.UTC),
+			Testname: "TestFractionalSeconds",
+		},
+		{
+			Date:     "2011-10-03T11:32:05-07:00",
+			Location: time.UTC,
+			Expected: time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC),
+			Testname: "TestOffset",
+		},
+		{
+			Date:     "2011-10-03T18:32:05Z",
+			Location: pdt,
+			Expected: time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC),
+			Testname: "TestZulu",
+		},
+		{
+			Date:     "yesterday",
+			Location: time.UTC,
+			Fails:    true,
+			Testname: "TestInvalid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			posted, err := parseHNDate(test.Date, test.Location)
+
+			if test.Fails {
+				assert.NotNil(t, err)
+
+				return
+			}
+
+			assert.Nil(t, err)
+
+			assert.True(t, test.Expected.Equal(posted), "expected %v, got %v", test.Expected, posted)
+		})
+	}
+
+	// the timestamp following the date is split off
+	posted, timestamp, err := parseTimestamp("2011-10-03T11:32:05-07:00 1317666725", time.UTC)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, int64(1317666725), timestamp)
+
+	assert.Equal(t, time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC), posted)
+}
+
+// TestParseScore tests that the integer of a score is parsed
+// however the words around it are laid out.
+func TestParseScore(t *testing.T) {
+	tests := []struct {
+		Text     string
+		Points   int
+		Err      bool
+		Testname string
+	}{
+		{Text: "194 points", Points: 194, Testname: "TestPlural"},
+		{Text: "1 point", Points: 1, Testname: "TestSingular"},
+		{Text: "1,204 points", Points: 1204, Testname: "TestThousands"},
+		{Text: "score: 57 points", Points: 57, Testname: "TestLeadingWords"},
+		{Text: "57", Points: 57, Testname: "TestBareInteger"},
+		{Text: " 57  points ", Points: 57, Testname: "TestWhitespace"},
+		{Text: "many points", Err: true, Testname: "TestNoInteger"},
+		{Text: "", Err: true, Testname: "TestEmpty"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			points, err := parseScore(test.Text)
+
+			if test.Err {
+				var parseErr *ParseError
+
+				assert.True(t, errors.As(err, &parseErr))
+
+				return
+			}
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Points, points)
+		})
+	}
+}
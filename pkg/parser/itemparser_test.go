@@ -31,9 +31,14 @@ package parser_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -49,14 +54,16 @@ const dateLayout = "2006-01-02T15:04:05"
 // calling HN.
 func TestParserSamples(t *testing.T) {
 	type TestDef struct {
-		Title       model.Title
-		Author      string
-		Date        time.Time
-		ID          int
-		Points      int
-		NumComments int
-		Testfile    string
-		Testname    string
+		Title        model.Title
+		Domain       string
+		Author       string
+		Date         time.Time
+		ID           int
+		Points       int
+		NumComments  int
+		CommentCount int
+		Testfile     string
+		Testname     string
 	}
 
 	referenceOne, err := url.Parse("https://github.com/glenjamin/node-fib")
@@ -71,13 +78,15 @@ func TestParserSamples(t *testing.T) {
 				Name:      "Node-fib: Fast non-blocking fibonacci server",
 				Reference: referenceOne,
 			},
-			Author:      "dchest",
-			Testfile:    filepath.Join("testdata", "sample1.html"),
-			NumComments: 118,
-			Points:      194,
-			ID:          3067403,
-			Date:        dateOne,
-			Testname:    "TestSampleOne",
+			Domain:       "github.com/glenjamin",
+			Author:       "dchest",
+			Testfile:     filepath.Join("testdata", "sample1.html"),
+			NumComments:  118,
+			CommentCount: 118,
+			Points:       194,
+			ID:           3067403,
+			Date:         dateOne,
+			Testname:     "TestSampleOne",
 		},
 	}
 
@@ -95,6 +104,8 @@ func TestParserSamples(t *testing.T) {
 
 			assert.Equal(t, test.Title, parsed.Title)
 
+			assert.Equal(t, test.Domain, parsed.Domain)
+
 			assert.Equal(t, test.Author, parsed.Author)
 
 			assert.Equal(t, test.Points, parsed.Points)
@@ -104,7 +115,3312 @@ func TestParserSamples(t *testing.T) {
 			assert.Equal(t, test.Date, parsed.Date)
 
 			assert.Equal(t, test.NumComments, len(parsed.Comments))
+
+			assert.Equal(t, test.CommentCount, parsed.CommentCount)
+
+			assert.Nil(t, parsed.Validate())
+		})
+	}
+
+}
+
+// TestCommentDepth tests that the nesting depth of each comment
+// is derived from the indentation spacer.
+func TestCommentDepth(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	depths := make(map[int]int)
+
+	for _, comment := range parsed.Comments {
+		depths[comment.Depth]++
+	}
+
+	assert.Equal(t, 0, parsed.Comments[0].Depth)
+
+	assert.Equal(t, 1, parsed.Comments[2].Depth)
+
+	assert.Equal(t, 24, depths[0])
+
+	assert.Equal(t, 1, depths[8])
+}
+
+// TestParseHTMLWithContextCancelled tests that parsing stops
+// with the context's error once the context is cancelled.
+func TestParseHTMLWithContextCancelled(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancel()
+
+	_, err = parser.ParseHTMLWithContext(ctx, bytes.NewReader(sample))
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestItemText tests that the body of a self-post is extracted,
+// and that link submissions are left without one.
+func TestItemText(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Text     string
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Text:     "",
+			Testname: "TestLinkSubmission",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_ask.html"),
+			Text: "<div>I keep saving articles and never getting back to them.<p>What tools or habits " +
+				"work for you? See <a>my notes</a>.</p></div>",
+			Testname: "TestAskHN",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Text, parsed.Text)
+		})
+	}
+}
+
+// TestDeadComments tests that dead and flagged comments
+// are distinguished from regular ones.
+func TestDeadComments(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_dead.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, len(parsed.Comments))
+
+	assert.False(t, parsed.Comments[0].Dead)
+
+	assert.True(t, parsed.Comments[1].Dead)
+
+	assert.True(t, parsed.Comments[2].Dead)
+}
+
+// TestParentLinks tests that the parent of a comment is read
+// from each form of its parent link, and that a malformed link
+// leaves the parent unset without failing the parse.
+func TestParentLinks(t *testing.T) {
+	parentID := 3067434
+
+	tests := []struct {
+		Href     string
+		ParentID *int
+		Testname string
+	}{
+		{Href: "#3067434", ParentID: &parentID, Testname: "TestFragment"},
+		{Href: "item?id=3067434#3067434", ParentID: &parentID, Testname: "TestItemAndFragment"},
+		{Href: "item?id=3067434", ParentID: &parentID, Testname: "TestItem"},
+		{Href: "https://news.ycombinator.com/item?id=3067434", ParentID: &parentID, Testname: "TestAbsolute"},
+		{Href: "#top", ParentID: nil, Testname: "TestNonNumeric"},
+		{Href: "item?goto=news", ParentID: nil, Testname: "TestGoto"},
+		{Href: "%zz", ParentID: nil, Testname: "TestUnparseable"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			doc := `<table class="comment-tree"><tr class="athing comtr" id="3067519"><td><table><tr><td class="default">` +
+				`<span class="comhead"><a href="user?id=x" class="hnuser">x</a> <span class="navs">` +
+				`| <a href="` + test.Href + `" class="clicky">parent</a></span></span>` +
+				`<div class="comment"><div class="commtext c00">Yes.</div></div></td></tr></table></td></tr>` +
+				`<tr class="athing comtr" id="3067520"><td><table><tr><td class="default">` +
+				`<span class="comhead"><a href="user?id=y" class="hnuser">y</a></span>` +
+				`<div class="comment"><div class="commtext c00">No.</div></div></td></tr></table></td></tr></table>`
+
+			parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+			assert.Nil(t, err)
+
+			// the other comments are unaffected
+			if assert.Len(t, parsed.Comments, 2) {
+				assert.Equal(t, test.ParentID, parsed.Comments[0].ParentID)
+
+				assert.Equal(t, "y", parsed.Comments[1].Author)
+			}
+		})
+	}
+}
+
+// TestParseError tests that malformed values are reported
+// as a parser.ParseError naming the offending field.
+func TestParseError(t *testing.T) {
+	doc := `<table class="fatitem"><tr class="athing" id="abc"><td class="title"></td></tr></table>`
+
+	_, err := parser.ParseHTML(strings.NewReader(doc))
+
+	var parseErr *parser.ParseError
+
+	assert.True(t, errors.As(err, &parseErr))
+
+	assert.Equal(t, "id", parseErr.Field)
+
+	assert.Equal(t, "abc", parseErr.Raw)
+
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
+
+// TestScore tests that scores are extracted in both
+// their singular and plural forms.
+func TestScore(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Points   int
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Points:   194,
+			Testname: "TestPlural",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_ask.html"),
+			Points:   1,
+			Testname: "TestSingular",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Points, parsed.Points)
+		})
+	}
+}
+
+// TestReorderedSubline tests that the fields of a subline are extracted
+// regardless of their order, and that a score is extracted regardless of
+// the words around it.
+func TestReorderedSubline(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_reordered_subline.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 8900000, parsed.ID)
+
+	assert.Equal(t, 1204, parsed.Points)
+
+	assert.Equal(t, "dorothea", parsed.Author)
+
+	assert.Equal(t, time.Date(2024, time.March, 5, 8, 30, 0, 0, time.UTC), parsed.Date)
+
+	assert.Equal(t, 3, parsed.CommentCount)
+
+	assert.True(t, parsed.Found.Score)
+
+	assert.True(t, parsed.Found.Author)
+
+	assert.True(t, parsed.Found.Date)
+}
+
+// TestMalformedScore tests that a score without a leading
+// integer is reported rather than silently dropped.
+func TestMalformedScore(t *testing.T) {
+	doc := `<span class="subline"><span class="score">many points</span></span>`
+
+	_, err := parser.ParseHTML(strings.NewReader(doc))
+
+	var parseErr *parser.ParseError
+
+	assert.True(t, errors.As(err, &parseErr))
+
+	assert.Equal(t, "score", parseErr.Field)
+}
+
+// TestMalformedDepth tests that a comment whose indentation cannot
+// be parsed is reported along with the field and the raw width.
+func TestMalformedDepth(t *testing.T) {
+	doc := `<table class="comment-tree"><tr class="athing comtr" id="3067434"><td><table><tr>` +
+		`<td class="ind"><img src="s.gif" height="1" width="wide"></td><td class="default"></td>` +
+		`</tr></table></td></tr></table>`
+
+	_, err := parser.ParseHTML(strings.NewReader(doc))
+
+	var commentErr *parser.CommentError
+
+	if assert.True(t, errors.As(err, &commentErr)) {
+		assert.Equal(t, 3067434, commentErr.ID)
+	}
+
+	var parseErr *parser.ParseError
+
+	if assert.True(t, errors.As(err, &parseErr)) {
+		assert.Equal(t, "depth", parseErr.Field)
+
+		assert.Equal(t, "wide", parseErr.Raw)
+	}
+
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+}
+
+// TestCommentCountFormats tests that the number of comments is parsed
+// with any thousands separators, and that a malformed count is reported
+// along with the field and the raw value.
+func TestCommentCountFormats(t *testing.T) {
+	tests := []struct {
+		Link     string
+		Count    int
+		Raw      string
+		Testname string
+	}{
+		{Link: "118&nbsp;comments", Count: 118, Testname: "TestPlural"},
+		{Link: "1&nbsp;comment", Count: 1, Testname: "TestSingular"},
+		{Link: "1,204&nbsp;comments", Count: 1204, Testname: "TestThousands"},
+		{Link: "discuss", Count: 0, Testname: "TestDiscuss"},
+		{Link: "many&nbsp;comments", Raw: "many", Testname: "TestMalformed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			doc := `<span class="subline"><a href="item?id=3067403">` + test.Link + `</a></span>`
+
+			parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+			if test.Raw == "" {
+				assert.Nil(t, err)
+
+				assert.Equal(t, test.Count, parsed.CommentCount)
+
+				return
+			}
+
+			var parseErr *parser.ParseError
+
+			if assert.True(t, errors.As(err, &parseErr)) {
+				assert.Equal(t, "comment count", parseErr.Field)
+
+				assert.Equal(t, test.Raw, parseErr.Raw)
+			}
+		})
+	}
+}
+
+// TestJob tests that job postings, which have neither a score
+// nor an author, are told apart from stories.
+func TestJob(t *testing.T) {
+	date, err := time.Parse(dateLayout, "2024-08-14T17:00:42")
+
+	assert.Nil(t, err)
+
+	tests := []struct {
+		Testfile string
+		IsJob    bool
+		Date     time.Time
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample_job.html"),
+			IsJob:    true,
+			Date:     date,
+			Testname: "TestJobPosting",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_ask.html"),
+			IsJob:    false,
+			Date:     time.Date(2012, time.June, 11, 9, 15, 42, 0, time.UTC),
+			Testname: "TestStory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.IsJob, parsed.IsJob)
+
+			assert.Equal(t, test.Date, parsed.Date)
 		})
 	}
+}
+
+// TestCommentScore tests that the score of a comment is only
+// extracted when the comment header shows one.
+func TestCommentScore(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_loggedin.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 57, parsed.Points)
+
+	assert.Equal(t, 2, len(parsed.Comments))
+
+	if assert.NotNil(t, parsed.Comments[0].Points) {
+		assert.Equal(t, 3, *parsed.Comments[0].Points)
+	}
+
+	assert.Nil(t, parsed.Comments[1].Points)
+}
+
+// TestReplyURL tests that the reply link of a comment is resolved
+// against the base URL, and is absent on archived pages.
+func TestReplyURL(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_loggedin.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	if assert.NotNil(t, parsed.Comments[0].ReplyURL) {
+		assert.Equal(t, "https://news.ycombinator.com/reply?id=6300001&goto=item%3Fid%3D6300000%236300001", parsed.Comments[0].ReplyURL.String())
+	}
+
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	for _, comment := range parsed.Comments {
+		assert.Nil(t, comment.ReplyURL)
+	}
+}
+
+// TestPermalinkURL tests that the permalink of each comment is taken
+// from the link of its age, resolved against the base URL.
+func TestPermalinkURL(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	for _, comment := range parsed.Comments {
+		if assert.NotNil(t, comment.PermalinkURL) {
+			assert.Equal(t, "https://news.ycombinator.com/item?id="+strconv.Itoa(comment.ID), comment.PermalinkURL.String())
+		}
+	}
+
+	base, err := url.Parse("https://hn.example.org/")
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTMLWithBase(bytes.NewReader(sample), base)
+
+	assert.Nil(t, err)
+
+	if assert.NotNil(t, parsed.Comments[0].PermalinkURL) {
+		assert.Equal(t, "https://hn.example.org/item?id=3067434", parsed.Comments[0].PermalinkURL.String())
+	}
+}
+
+// TestVoteable tests that items and comments are only voteable
+// when they carry an upvote arrow.
+func TestVoteable(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_loggedin.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.True(t, parsed.Voteable)
+
+	for _, comment := range parsed.Comments {
+		assert.True(t, comment.Voteable)
+	}
+
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample_job.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.False(t, parsed.Voteable)
+
+	doc := `<table class="comment-tree"><tr class="athing comtr" id="3067434"><td><table><tr>` +
+		`<td class="default"><span class="comhead"><a href="user?id=x" class="hnuser">x</a></span></td>` +
+		`</tr></table></td></tr></table>`
+
+	parsed, err = parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	if assert.Len(t, parsed.Comments, 1) {
+		assert.False(t, parsed.Comments[0].Voteable)
+	}
+}
+
+// TestEditDeleteLinks tests that the links that edit and
+// delete an item and its comments are extracted from a page
+// viewed by their author, and are nil otherwise.
+func TestEditDeleteLinks(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_authored.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, parsed.Validate())
+
+	if assert.NotNil(t, parsed.EditURL) {
+		assert.Equal(t, "https://news.ycombinator.com/edit?id=6400000", parsed.EditURL.String())
+	}
+
+	if assert.NotNil(t, parsed.DeleteURL) {
+		assert.Equal(t, "https://news.ycombinator.com/delete-confirm?id=6400000&goto=item%3Fid%3D6400000", parsed.DeleteURL.String())
+	}
+
+	assert.Equal(t, 2, parsed.CommentCount)
+
+	assert.Nil(t, parsed.RelatedDiscussions)
+
+	if assert.Len(t, parsed.Comments, 2) {
+		// the first comment is by another user
+		assert.Nil(t, parsed.Comments[0].EditURL)
+
+		assert.Nil(t, parsed.Comments[0].DeleteURL)
+
+		mine := parsed.Comments[1]
 
+		assert.Equal(t, "selfposter", mine.Author)
+
+		if assert.NotNil(t, mine.EditURL) {
+			assert.Equal(t, "https://news.ycombinator.com/edit?id=6400017", mine.EditURL.String())
+		}
+
+		if assert.NotNil(t, mine.DeleteURL) {
+			assert.Equal(t, "https://news.ycombinator.com/delete-confirm?id=6400017&goto=item%3Fid%3D6400000%236400017", mine.DeleteURL.String())
+		}
+
+		if assert.NotNil(t, mine.ReplyURL) {
+			assert.Equal(t, "https://news.ycombinator.com/reply?id=6400017&goto=item%3Fid%3D6400000%236400017", mine.ReplyURL.String())
+		}
+	}
+
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, parsed.EditURL)
+
+	assert.Nil(t, parsed.DeleteURL)
+
+	for _, comment := range parsed.Comments {
+		assert.Nil(t, comment.EditURL)
+
+		assert.Nil(t, comment.DeleteURL)
+	}
+}
+
+// TestIsOP tests that the comments written by the author
+// of the item are marked, whichever way they are parsed.
+func TestIsOP(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_authored.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	var marked []bool
+
+	for _, comment := range parsed.Comments {
+		marked = append(marked, comment.IsOP)
+	}
+
+	assert.Equal(t, []bool{false, true}, marked)
+
+	item, comments, errs := parser.ParseHTMLStream(context.Background(), bytes.NewReader(sample))
+
+	assert.Equal(t, "selfposter", item.Author)
+
+	var streamed []bool
+
+	for comment := range comments {
+		streamed = append(streamed, comment.IsOP)
+	}
+
+	assert.Nil(t, <-errs)
+
+	assert.Equal(t, marked, streamed)
+
+	_, index, err := parser.ParseIndex(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	comment, err := index.Comment(6400017)
+
+	assert.Nil(t, err)
+
+	if assert.NotNil(t, comment) {
+		assert.True(t, comment.IsOP)
+	}
+
+	// comments without an author are never marked
+	doc := `<table class="comment-tree"><tr class="athing comtr" id="3067434"><td><table><tr>` +
+		`<td class="default"><span class="comhead"></span></td></tr></table></td></tr></table>`
+
+	parsed, err = parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	if assert.Len(t, parsed.Comments, 1) {
+		assert.False(t, parsed.Comments[0].IsOP)
+	}
+}
+
+// TestUpvoted tests that an item is found to be upvoted
+// when its upvote arrow was hidden after a vote.
+func TestUpvoted(t *testing.T) {
+	tests := []struct {
+		Arrow    string
+		Upvoted  bool
+		Testname string
+	}{
+		{
+			Arrow:    `<a id="up_4200" href="vote?id=4200&amp;how=up"><div class="votearrow"></div></a>`,
+			Upvoted:  false,
+			Testname: "TestVisibleArrow",
+		},
+		{
+			Arrow:    `<a id="up_4200" style="visibility: hidden" href="vote?id=4200&amp;how=up"><div class="votearrow"></div></a>`,
+			Upvoted:  true,
+			Testname: "TestHiddenStyle",
+		},
+		{
+			Arrow:    `<a id="up_4200" class="clicky nosee" href="vote?id=4200&amp;how=up"><div class="votearrow"></div></a>`,
+			Upvoted:  true,
+			Testname: "TestHiddenClass",
+		},
+		{
+			Arrow:    ``,
+			Upvoted:  false,
+			Testname: "TestMissingArrow",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			doc := `<table><tr class="athing" id="4200"><td class="votelinks">` + test.Arrow + `</td>` +
+				`<td class="title"><span class="titleline"><a href="x">x</a></span></td></tr></table>`
+
+			parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Upvoted, parsed.Upvoted)
+
+			assert.Equal(t, test.Arrow != "", parsed.Voteable)
+		})
+	}
+}
+
+// TestPageTitle tests that the title of the page matches
+// the name of the item, and remains available when the
+// markup of the title is not recognized.
+func TestPageTitle(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Testname: "TestStory",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_job.html"),
+			Testname: "TestJob",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_poll.html"),
+			Testname: "TestPoll",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.NotEmpty(t, parsed.PageTitle)
+
+			assert.Equal(t, parsed.Title.Name, parsed.PageTitle)
+		})
+	}
+
+	doc := `<html><head><title>Node-fib: Fast non-blocking fibonacci server | Hacker News</title></head><body>` +
+		`<table><tr class="athing" id="3067403"><td class="title"><span class="headline"><a href="x">x</a></span></td></tr>` +
+		`</table></body></html>`
+
+	parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Empty(t, parsed.Title.Name)
+
+	assert.Equal(t, "Node-fib: Fast non-blocking fibonacci server", parsed.PageTitle)
+}
+
+// TestTopLevelCommentCount tests that only the comments
+// replying to the item directly are counted as top-level.
+func TestTopLevelCommentCount(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		TopLevel int
+		Comments int
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			TopLevel: 24,
+			Comments: 118,
+			Testname: "TestDeepThread",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_paginated.html"),
+			TopLevel: 2,
+			Comments: 3,
+			Testname: "TestPaginatedThread",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_job.html"),
+			TopLevel: 0,
+			Comments: 0,
+			Testname: "TestNoComments",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.TopLevel, parsed.TopLevelCommentCount)
+
+			assert.Len(t, parsed.Comments, test.Comments)
+		})
+	}
+}
+
+// TestRelatedDiscussions tests that the links to past discussions
+// are told apart from the standard links of the subline.
+func TestRelatedDiscussions(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_repost.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	var related []string
+
+	for _, link := range parsed.RelatedDiscussions {
+		related = append(related, link.String())
+	}
+
+	assert.Equal(t, []string{
+		"https://news.ycombinator.com/item?id=4500123",
+		"https://news.ycombinator.com/item?id=7100456",
+	}, related)
+
+	assert.Equal(t, 0, parsed.CommentCount)
+
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, parsed.RelatedDiscussions)
+}
+
+// TestModerationLinks tests that the links that hide and
+// flag an item are extracted from its subline, and that
+// the comments link is not mistaken for either of them.
+func TestModerationLinks(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	if assert.NotNil(t, parsed.HideURL) {
+		assert.Equal(t, "https://news.ycombinator.com/hide?id=3067403&goto=item%3Fid%3D3067403", parsed.HideURL.String())
+	}
+
+	assert.Nil(t, parsed.FlagURL)
+
+	doc := `<table><tr class="athing" id="4200"><td class="title"><span class="titleline"><a href="x">x</a></span></td></tr>` +
+		`<tr><td class="subtext"><span class="subline"><a href="user?id=x" class="hnuser">x</a> | ` +
+		`<a href="item?id=4200">12&nbsp;comments</a> | <a href="flag?id=4200&amp;auth=abc&amp;goto=item%3Fid%3D4200">flag</a> | ` +
+		`<a href="hide?id=4200&amp;auth=abc&amp;goto=item%3Fid%3D4200">hide</a></span></td></tr></table>`
+
+	parsed, err = parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	if assert.NotNil(t, parsed.HideURL) {
+		assert.Equal(t, "https://news.ycombinator.com/hide?id=4200&auth=abc&goto=item%3Fid%3D4200", parsed.HideURL.String())
+	}
+
+	if assert.NotNil(t, parsed.FlagURL) {
+		assert.Equal(t, "https://news.ycombinator.com/flag?id=4200&auth=abc&goto=item%3Fid%3D4200", parsed.FlagURL.String())
+	}
+}
+
+// TestKind tests that the kind of an item is inferred
+// from its page.
+func TestKind(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Kind     model.Kind
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Kind:     model.KindStory,
+			Testname: "TestStory",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_ask.html"),
+			Kind:     model.KindAsk,
+			Testname: "TestAsk",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_job.html"),
+			Kind:     model.KindJob,
+			Testname: "TestJob",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_poll.html"),
+			Kind:     model.KindPoll,
+			Testname: "TestPoll",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Kind, parsed.Kind)
+		})
+	}
+
+	doc := `<table class="fatitem"><tr class="athing" id="3067434"><td class="default">` +
+		`<span class="comhead"><a href="user?id=x" class="hnuser">x</a></span></td></tr></table>`
+
+	parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, model.KindComment, parsed.Kind)
+}
+
+// TestFocusedComment tests that the permalink page of a comment
+// is given the context of its story, with the focused comment
+// leading its replies, whichever way the page is parsed.
+func TestFocusedComment(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_permalink.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	// the item is that of the story, whose author,
+	// points and date do not appear on the page
+	assert.Equal(t, model.KindStory, parsed.Kind)
+
+	assert.Equal(t, "", parsed.Author)
+
+	assert.Equal(t, 0, parsed.Points)
+
+	assert.True(t, parsed.Date.IsZero())
+
+	assert.False(t, parsed.Voteable)
+
+	assert.Equal(t, model.FieldsFound{Title: true, ID: true}, parsed.Found)
+
+	assert.Equal(t, 8300000, parsed.ID)
+
+	assert.Equal(t, "Why SQLite uses bytecode", parsed.Title.Name)
+
+	assert.Equal(t, "https://news.ycombinator.com/item?id=8300000", parsed.Title.Reference.String())
+
+	if assert.NotNil(t, parsed.FocusedCommentID) {
+		assert.Equal(t, 8300123, *parsed.FocusedCommentID)
+	}
+
+	focusedID := 8300123
+
+	parentID := 8300050
+
+	replyID := 8300200
+
+	type summary struct {
+		ID       int
+		Author   string
+		Depth    int
+		ParentID *int
+	}
+
+	expected := []summary{
+		{ID: 8300123, Author: "vdbe", Depth: 0, ParentID: &parentID},
+		{ID: 8300200, Author: "planner", Depth: 1, ParentID: &focusedID},
+		{ID: 8300211, Author: "vdbe", Depth: 2, ParentID: &replyID},
+	}
+
+	summarize := func(comments []model.Comment) []summary {
+		var summaries []summary
+
+		for _, comment := range comments {
+			summaries = append(summaries, summary{
+				ID:       comment.ID,
+				Author:   comment.Author,
+				Depth:    comment.Depth,
+				ParentID: comment.ParentID,
+			})
+		}
+
+		return summaries
+	}
+
+	assert.Equal(t, expected, summarize(parsed.Comments))
+
+	if assert.NotEmpty(t, parsed.Comments) {
+		focused := parsed.Comments[0]
+
+		assert.Equal(t, time.Date(2024, time.May, 21, 14, 2, 11, 0, time.UTC), focused.Date)
+
+		assert.Equal(t, "The bytecode is also what EXPLAIN prints, which makes the query planner far easier to debug.\n\n"+
+			"A tree of objects would hide that.", focused.PlainText())
+
+		if assert.NotNil(t, focused.StoryID) {
+			assert.Equal(t, 8300000, *focused.StoryID)
+		}
+	}
+
+	// the author of the focused comment is not the author of the story
+	for _, comment := range parsed.Comments {
+		assert.False(t, comment.IsOP, "comment %d", comment.ID)
+	}
+
+	assert.Equal(t, []string{"vdbe", "planner"}, parsed.Participants)
+
+	// the replies form a single tree beneath the focused comment
+	roots := parser.BuildCommentTree(parsed.Comments)
+
+	if assert.Len(t, roots, 1) {
+		assert.Equal(t, 8300123, roots[0].Comment.ID)
+	}
+
+	item, comments, errs := parser.ParseHTMLStream(context.Background(), bytes.NewReader(sample))
+
+	var streamed []model.Comment
+
+	for comment := range comments {
+		streamed = append(streamed, comment)
+	}
+
+	assert.Nil(t, <-errs)
+
+	assert.Equal(t, parsed.ID, item.ID)
+
+	assert.Equal(t, parsed.Kind, item.Kind)
+
+	// a comment is streamed before its replies are read, so
+	// unlike ParseHTML, it does not count them
+	if assert.Len(t, streamed, len(parsed.Comments)) {
+		for i := range streamed {
+			assert.Zero(t, streamed[i].ReplyCount)
+
+			streamed[i].ReplyCount = parsed.Comments[i].ReplyCount
+		}
+	}
+
+	assert.Equal(t, parsed.Comments, streamed)
+
+	item, index, err := parser.ParseIndex(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, parsed.FocusedCommentID, item.FocusedCommentID)
+
+	assert.Equal(t, []int{8300123, 8300200, 8300211}, index.IDs())
+
+	for _, expected := range parsed.Comments {
+		comment, err := index.Comment(expected.ID)
+
+		assert.Nil(t, err)
+
+		if assert.NotNil(t, comment) {
+			assert.Equal(t, expected, *comment)
+		}
+	}
+
+	// the story of an item page has no focused comment
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, parsed.FocusedCommentID)
+}
+
+// TestNavLinks tests that the root, next, and previous comments are
+// taken from the navigation links of each comment, whether they point
+// within the page or at pages of their own, and are nil when absent.
+func TestNavLinks(t *testing.T) {
+	type navs struct {
+		RootID *int
+		NextID *int
+		PrevID *int
+	}
+
+	id := func(n int) *int {
+		return &n
+	}
+
+	tests := []struct {
+		Filename string
+		Expected map[int]navs
+		Testname string
+	}{
+		{
+			Filename: "sample1.html",
+			Expected: map[int]navs{
+				3067434: {NextID: id(3067519)},
+				3067519: {NextID: id(3069308), PrevID: id(3067434)},
+				3067729: {RootID: id(3067519), NextID: id(3068911)},
+			},
+			Testname: "TestThread",
+		},
+		{
+			Filename: "sample_permalink.html",
+			Expected: map[int]navs{
+				8300123: {RootID: id(8300011), NextID: id(8300140)},
+				8300200: {},
+				8300211: {},
+			},
+			Testname: "TestPermalink",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(filepath.Join("testdata", test.Filename))
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			actual := make(map[int]navs)
+
+			for _, comment := range parsed.Comments {
+				if _, ok := test.Expected[comment.ID]; ok {
+					actual[comment.ID] = navs{RootID: comment.RootID, NextID: comment.NextID, PrevID: comment.PrevID}
+				}
+			}
+
+			assert.Equal(t, test.Expected, actual)
+		})
+	}
+}
+
+// TestReplyCount tests that the number of direct replies to each
+// comment is taken from its annotation where the markup shows one,
+// even when it is zero, and is otherwise counted among the replies
+// on the page.
+func TestReplyCount(t *testing.T) {
+	tests := []struct {
+		Filename string
+		Expected map[int]int
+		Testname string
+	}{
+		{
+			Filename: "sample_replies.html",
+			Expected: map[int]int{8400101: 4, 8400150: 0, 8400177: 1, 8400190: 1},
+			Testname: "TestAnnotated",
+		},
+		{
+			Filename: "sample_replies_zero.html",
+			Expected: map[int]int{8410101: 4, 8410150: 0, 8410177: 0, 8410190: 1},
+			Testname: "TestAnnotatedZero",
+		},
+		{
+			Filename: "sample_permalink.html",
+			Expected: map[int]int{8300123: 1, 8300200: 1, 8300211: 0},
+			Testname: "TestFocusedComment",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(filepath.Join("testdata", test.Filename))
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			counts := make(map[int]int)
+
+			for _, comment := range parsed.Comments {
+				counts[comment.ID] = comment.ReplyCount
+			}
+
+			assert.Equal(t, test.Expected, counts)
+		})
+	}
+
+	// without annotations, the counts match the reply tree
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	var visit func(nodes []*model.CommentNode)
+
+	visit = func(nodes []*model.CommentNode) {
+		for _, node := range nodes {
+			assert.Equal(t, len(node.Children), node.Comment.ReplyCount, "comment %d", node.Comment.ID)
+
+			visit(node.Children)
+		}
+	}
+
+	visit(parser.BuildCommentTree(parsed.Comments))
+}
+
+// TestParticipants tests that the participants of a thread are
+// the distinct authors of the item and its comments, in order.
+func TestParticipants(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "dchest", parsed.Participants[0])
+
+	assert.Equal(t, parsed.Comments[0].Author, parsed.Participants[1])
+
+	seen := make(map[string]bool)
+
+	for _, participant := range parsed.Participants {
+		assert.False(t, seen[participant])
+
+		seen[participant] = true
+	}
+
+	for _, comment := range parsed.Comments {
+		if comment.Author != "" {
+			assert.True(t, seen[comment.Author])
+		}
+	}
+}
+
+// TestCommentContentWhitespace tests that cleaning the content of a
+// comment keeps its code blocks verbatim and separates its paragraphs.
+func TestCommentContentWhitespace(t *testing.T) {
+	doc := `<table class="comment-tree"><tr class="athing comtr" id="1"><td><table><tr><td class="default">` +
+		`<div class="comment"><div class="commtext c00">  Try   this:<p>
+		<pre><code>func main() {
+    fmt.Println("hi")
+}</code></pre>
+		<p>It   works.   <p>Really. </div></div></td></tr></table></td></tr></table>`
+
+	parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	if assert.Len(t, parsed.Comments, 1) {
+		comment := parsed.Comments[0]
+
+		assert.Equal(t, "<div>Try this:\n\n<pre><code>func main() {\n    fmt.Println(&#34;hi&#34;)\n}</code></pre>\n\n<p>It works.</p>\n\n<p>Really.</p></div>", comment.Content)
+
+		assert.Equal(t, "Try this:\n\nfunc main() {\n    fmt.Println(\"hi\")\n}\n\nIt works.\n\nReally.", comment.PlainText())
+	}
+}
+
+// TestWordCount tests that the words of a comment are counted
+// on its plain text, and that empty and dead comments count zero.
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		Class     string
+		Text      string
+		WordCount int
+		Testname  string
+	}{
+		{
+			Class:     "commtext c00",
+			Text:      "Is it any good?",
+			WordCount: 4,
+			Testname:  "TestPlainText",
+		},
+		{
+			Class:     "commtext c00",
+			Text:      "  Try <i>this</i>:<p><a href=\"https://example.com\">the   docs</a>,\n\tthen <code>go vet</code>.</p>",
+			WordCount: 7,
+			Testname:  "TestMarkup",
+		},
+		{
+			Class:     "commtext c00",
+			Text:      "",
+			WordCount: 0,
+			Testname:  "TestEmpty",
+		},
+		{
+			Class:     "commtext cdd",
+			Text:      "Buy cheap watches here",
+			WordCount: 0,
+			Testname:  "TestDead",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			doc := `<table class="comment-tree"><tr class="athing comtr" id="1"><td><table><tr><td class="default">` +
+				`<div class="comment"><div class="` + test.Class + `">` + test.Text + `</div></div></td></tr></table></td></tr></table>`
+
+			parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+			assert.Nil(t, err)
+
+			if assert.Len(t, parsed.Comments, 1) {
+				assert.Equal(t, test.WordCount, parsed.Comments[0].WordCount)
+			}
+		})
+	}
+}
+
+// TestWithRawHTML tests that the raw HTML of a comment keeps
+// the whitespace of its code blocks, and is only kept on request.
+func TestWithRawHTML(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.New(parser.WithRawHTML(true)).ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	var comment *model.Comment
+
+	for i := range parsed.Comments {
+		if parsed.Comments[i].ID == 3068693 {
+			comment = &parsed.Comments[i]
+		}
+	}
+
+	if assert.NotNil(t, comment) {
+		assert.True(t, strings.HasPrefix(comment.RawHTML, `<div class="commtext c00">`))
+
+		assert.Contains(t, comment.RawHTML, "<pre><code>   memo function-to-memoize</code></pre>")
+
+		assert.True(t, strings.HasPrefix(comment.Content, "<div>"))
+	}
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	for _, comment := range parsed.Comments {
+		assert.Equal(t, "", comment.RawHTML)
+	}
+}
+
+// TestWithCommentContentCleaner tests that a custom cleaner
+// receives the raw HTML of each comment and that its result
+// becomes the content of the comment.
+func TestWithCommentContentCleaner(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	raw, err := parser.New(parser.WithRawHTML(true)).ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	cleaner := func(rawHTML string) string {
+		return strings.ToUpper(rawHTML)
+	}
+
+	parsed, err := parser.New(parser.WithCommentContentCleaner(cleaner)).ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	if assert.Len(t, parsed.Comments, len(raw.Comments)) {
+		for i, comment := range parsed.Comments {
+			assert.Equal(t, strings.ToUpper(raw.Comments[i].RawHTML), comment.Content)
+		}
+	}
+
+	// a nil cleaner restores the default
+	defaulted, err := parser.New(parser.WithCommentContentCleaner(nil)).ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	expected, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, expected, defaulted)
+}
+
+// TestBuildCommentTree tests that the reply tree reconstructed
+// from the parent pointers agrees with the comment depths.
+func TestBuildCommentTree(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	roots := parser.BuildCommentTree(parsed.Comments)
+
+	assert.Equal(t, 24, len(roots))
+
+	var count int
+
+	var walk func(nodes []*model.CommentNode, depth int)
+
+	walk = func(nodes []*model.CommentNode, depth int) {
+		for _, node := range nodes {
+			count++
+
+			assert.Equal(t, depth, node.Comment.Depth)
+
+			walk(node.Children, depth+1)
+		}
+	}
+
+	walk(roots, 0)
+
+	assert.Equal(t, len(parsed.Comments), count)
+}
+
+// TestFlattenCommentTree tests that flattening the tree built
+// from the comments of a thread yields them back in order, and
+// that pruning a subtree drops its replies with it.
+func TestFlattenCommentTree(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	roots := parser.BuildCommentTree(parsed.Comments)
+
+	assert.Equal(t, parsed.Comments, parser.FlattenCommentTree(roots))
+
+	// prune the first root along with its replies
+	pruned := parser.FlattenCommentTree(roots[1:])
+
+	assert.Equal(t, parsed.Comments[len(roots[0].Flatten()):], pruned)
+
+	assert.Nil(t, parser.FlattenCommentTree(nil))
+}
+
+// TestWithCommentTree tests that the reply tree built while parsing agrees with
+// the one reconstructed from the parent pointers, and that it is only built when
+// requested.
+func TestWithCommentTree(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Options  []parser.Option
+		Roots    int
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Roots:    24,
+			Testname: "TestThread",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Options:  []parser.Option{parser.WithMaxDepth(1)},
+			Roots:    24,
+			Testname: "TestMaxDepth",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_replies.html"),
+			Options:  []parser.Option{parser.WithMaxComments(3)},
+			Roots:    2,
+			Testname: "TestMaxComments",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_permalink.html"),
+			Roots:    1,
+			Testname: "TestFocusedComment",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_permalink.html"),
+			Options:  []parser.Option{parser.WithMaxDepth(0)},
+			Roots:    1,
+			Testname: "TestFocusedCommentMaxDepth",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_permalink.html"),
+			Options:  []parser.Option{parser.WithMaxComments(1)},
+			Roots:    1,
+			Testname: "TestFocusedCommentMaxComments",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.New(append(test.Options, parser.WithCommentTree(true))...).ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Roots, len(parsed.CommentRoots))
+
+			assert.Equal(t, parser.BuildCommentTree(parsed.Comments), parsed.CommentRoots)
+
+			assert.Equal(t, parsed.Comments, parser.FlattenCommentTree(parsed.CommentRoots))
+
+			// the tree is not built by default
+			parsed, err = parser.New(test.Options...).ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Nil(t, parsed.CommentRoots)
+		})
+	}
+}
+
+// TestBuildCommentTreeOrphans tests that comments whose parent
+// is absent are attached as roots.
+func TestBuildCommentTreeOrphans(t *testing.T) {
+	missing := 1
+
+	parent := 2
+
+	comments := []model.Comment{
+		{ID: 2},
+		{ID: 3, ParentID: &parent},
+		{ID: 4, ParentID: &missing},
+	}
+
+	roots := parser.BuildCommentTree(comments)
+
+	assert.Equal(t, 2, len(roots))
+
+	assert.Equal(t, 2, roots[0].Comment.ID)
+
+	assert.Equal(t, 3, roots[0].Children[0].Comment.ID)
+
+	assert.Equal(t, 4, roots[1].Comment.ID)
+}
+
+// TestCommentLinks tests that the links shared within
+// a comment are preserved.
+func TestCommentLinks(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	var links []string
+
+	for _, comment := range parsed.Comments {
+		links = append(links, comment.Links...)
+	}
+
+	assert.Contains(t, links, "http://xkcd.com/386/")
+
+	assert.Contains(t, links, "https://gist.github.com/1258982")
+
+	assert.Nil(t, parsed.Comments[0].Links)
+}
+
+// TestWithElements tests that only the configured element
+// types are processed for data extraction.
+func TestWithElements(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.New(parser.WithElements("tr")).ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	// the ID lies on a row, while the title lies in a cell
+	assert.Equal(t, 3067403, parsed.ID)
+
+	assert.Equal(t, "", parsed.Title.Name)
+
+	assert.Nil(t, parsed.Comments)
+}
+
+// TestPoll tests that the options of a poll are extracted,
+// and that other items are left without a poll.
+func TestPoll(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_poll.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 7000000, parsed.ID)
+
+	assert.Equal(t, &model.Poll{
+		Options: []model.PollOption{
+			{Text: "Vim", Points: 120},
+			{Text: "Emacs", Points: 87},
+			{Text: "Something else entirely", Points: 1},
+		},
+	}, parsed.Poll)
+
+	assert.Equal(t, 1, len(parsed.Comments))
+
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, parsed.Poll)
+}
+
+// TestPollScore tests that the scores of the options of a
+// poll are not mistaken for the score of the poll, even when
+// the options lay out their scores in a subline.
+func TestPollScore(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample_poll.html"),
+			Testname: "TestCommentHeader",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_poll_subline.html"),
+			Testname: "TestSubline",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, 230, parsed.Points)
+
+			assert.Equal(t, "pollster", parsed.Author)
+
+			if assert.NotNil(t, parsed.Poll) {
+				assert.Equal(t, []model.PollOption{
+					{Text: "Vim", Points: 120},
+					{Text: "Emacs", Points: 87},
+					{Text: "Something else entirely", Points: 1},
+				}, parsed.Poll.Options)
+			}
+		})
+	}
+}
+
+// TestTimestamp tests that the Unix timestamp is taken from the
+// title of the age node when present, and derived otherwise.
+func TestTimestamp(t *testing.T) {
+	tests := []struct {
+		Doc       string
+		Timestamp int64
+		Date      time.Time
+		Testname  string
+	}{
+		{
+			Doc:       `<span class="subline"><span class="age" title="2011-10-03T18:32:05 1317666726"></span></span>`,
+			Timestamp: 1317666726,
+			Date:      time.Date(2011, time.October, 3, 18, 32, 6, 0, time.UTC),
+			Testname:  "TestEpoch",
+		},
+		{
+			Doc:       `<span class="subline"><span class="age" title="2011-10-03T18:32:05"></span></span>`,
+			Timestamp: 1317666725,
+			Date:      time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC),
+			Testname:  "TestDerived",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			parsed, err := parser.ParseHTML(strings.NewReader(test.Doc))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Timestamp, parsed.Timestamp)
+
+			assert.Equal(t, test.Date, parsed.Date)
+		})
+	}
+}
+
+// TestAgeText tests that the relative age text rendered by HN
+// is captured alongside the date.
+func TestAgeText(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "on Oct 3, 2011", parsed.AgeText)
+
+	assert.Equal(t, "on Oct 3, 2011", parsed.Comments[0].AgeText)
+}
+
+// TestRelativeAge tests that the date falls back to the age
+// text when the title attribute is missing.
+func TestRelativeAge(t *testing.T) {
+	now := time.Date(2024, time.September, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		AgeText  string
+		Date     time.Time
+		Testname string
+	}{
+		{
+			AgeText:  "1 minute ago",
+			Date:     time.Date(2024, time.September, 10, 11, 59, 0, 0, time.UTC),
+			Testname: "TestMinute",
+		},
+		{
+			AgeText:  "5 hours ago",
+			Date:     time.Date(2024, time.September, 10, 7, 0, 0, 0, time.UTC),
+			Testname: "TestHours",
+		},
+		{
+			AgeText:  "3 days ago",
+			Date:     time.Date(2024, time.September, 7, 12, 0, 0, 0, time.UTC),
+			Testname: "TestDays",
+		},
+		{
+			AgeText:  "2 months ago",
+			Date:     time.Date(2024, time.July, 10, 12, 0, 0, 0, time.UTC),
+			Testname: "TestMonths",
+		},
+		{
+			AgeText:  "on Oct 3, 2011",
+			Date:     time.Date(2011, time.October, 3, 0, 0, 0, 0, time.UTC),
+			Testname: "TestAbsolute",
+		},
+	}
+
+	p := parser.New(parser.WithNow(func() time.Time { return now }))
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			doc := `<span class="subline"><span class="age"><a href="item?id=1">` + test.AgeText + `</a></span></span>`
+
+			parsed, err := p.ParseHTML(strings.NewReader(doc))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.AgeText, parsed.AgeText)
+
+			assert.Equal(t, test.Date, parsed.Date)
+
+			assert.Equal(t, test.Date.Unix(), parsed.Timestamp)
+		})
+	}
+
+	doc := `<span class="subline"><span class="age"><a href="item?id=1">a while back</a></span></span>`
+
+	_, err := p.ParseHTML(strings.NewReader(doc))
+
+	var parseErr *parser.ParseError
+
+	if assert.True(t, errors.As(err, &parseErr)) {
+		assert.Equal(t, "date", parseErr.Field)
+
+		assert.Equal(t, "a while back", parseErr.Raw)
+	}
+}
+
+// TestWithTimeLocation tests that dates are interpreted in
+// the configured location.
+func TestWithTimeLocation(t *testing.T) {
+	loc := time.FixedZone("UTC-4", -4*60*60)
+
+	doc := `<span class="subline"><span class="age" title="2011-10-03T18:32:05"></span></span>`
+
+	parsed, err := parser.New(parser.WithTimeLocation(loc)).ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, time.Date(2011, time.October, 3, 18, 32, 5, 0, loc), parsed.Date)
+
+	assert.Equal(t, int64(1317666725+4*60*60), parsed.Timestamp)
+
+	// a nil location selects UTC
+	parsed, err = parser.New(parser.WithTimeLocation(nil)).ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, time.Date(2011, time.October, 3, 18, 32, 5, 0, time.UTC), parsed.Date)
+}
+
+// TestParseHTMLStream tests that streamed comments match
+// the comments of a regular parse.
+func TestParseHTMLStream(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	expected, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	item, comments, errs := parser.ParseHTMLStream(context.Background(), bytes.NewReader(sample))
+
+	assert.Equal(t, expected.Title, item.Title)
+
+	assert.Nil(t, item.Comments)
+
+	var streamed []model.Comment
+
+	for comment := range comments {
+		streamed = append(streamed, comment)
+	}
+
+	assert.Nil(t, <-errs)
+
+	// a comment is streamed before its replies are read, so
+	// unlike ParseHTML, it does not count them
+	if assert.Len(t, streamed, len(expected.Comments)) {
+		for i := range streamed {
+			assert.Zero(t, streamed[i].ReplyCount)
+
+			streamed[i].ReplyCount = expected.Comments[i].ReplyCount
+		}
+	}
+
+	assert.Equal(t, expected.Comments, streamed)
+}
+
+// TestParseHTMLStreamCancelled tests that the stream stops
+// with the context's error once the context is cancelled.
+func TestParseHTMLStreamCancelled(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, comments, errs := parser.ParseHTMLStream(ctx, bytes.NewReader(sample))
+
+	<-comments
+
+	cancel()
+
+	for range comments {
+	}
+
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
+
+// TestTitleWithoutText tests that a title anchor without
+// any text does not cause a panic.
+func TestTitleWithoutText(t *testing.T) {
+	doc := `<table><tr class="athing" id="3067403"><td class="title"><span class="titleline"><a href="item?id=3067403"></a></span></td></tr></table>`
+
+	parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3067403, parsed.ID)
+
+	assert.Equal(t, "", parsed.Title.Name)
+}
+
+// TestFormattedTitle tests that the text of a title whose anchor nests
+// inline formatting is extracted in full, rather than up to the first
+// formatted fragment.
+func TestFormattedTitle(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_formatted_title.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "The Mythical Man-Month, revisited fifty years on (2025)", parsed.Title.Name)
+
+	assert.True(t, parsed.Found.Title)
+
+	assert.Equal(t, "https://example.com/mythical-man-month", parsed.Title.Reference.String())
+
+	assert.Equal(t, parsed.PageTitle, parsed.Title.Name)
+
+	doc := `<table><tr class="athing" id="3067403"><td class="title"><span class="titleline">` +
+		`<a href="item?id=3067403"><i>Foo</i>  bar <b>baz</b></a></span></td></tr></table>`
+
+	parsed, err = parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "Foo bar baz", parsed.Title.Name)
+}
+
+// TestSelfPostTitle tests that the title of a self-post, whose
+// anchor is preceded by whitespace and links back to the item,
+// is extracted.
+func TestSelfPostTitle(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_ask.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "Ask HN: How do you keep up with your reading list?", parsed.Title.Name)
+
+	assert.Equal(t, "https://news.ycombinator.com/item?id=4100100", parsed.Title.Reference.String())
+
+	assert.Equal(t, "", parsed.Domain)
+}
+
+// TestWithBaseURL tests that relative references are resolved
+// against the configured base URL, and left as they are without one.
+func TestWithBaseURL(t *testing.T) {
+	base, err := url.Parse("https://mirror.example.com/hn/")
+
+	assert.Nil(t, err)
+
+	tests := []struct {
+		Base      *url.URL
+		Reference string
+		Testname  string
+	}{
+		{
+			Base:      base,
+			Reference: "https://mirror.example.com/hn/item?id=4100100",
+			Testname:  "TestCustomBase",
+		},
+		{
+			Base:      nil,
+			Reference: "item?id=4100100",
+			Testname:  "TestNoBase",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(filepath.Join("testdata", "sample_ask.html"))
+
+			assert.Nil(t, err)
+
+			p := parser.New(parser.WithBaseURL(test.Base))
+
+			parsed, err := p.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Reference, parsed.Title.Reference.String())
+		})
+	}
+}
+
+// TestEntities tests that entities left in titles and author
+// names, such as those escaped twice, are unescaped.
+func TestEntities(t *testing.T) {
+	tests := []struct {
+		Raw      string
+		Text     string
+		Testname string
+	}{
+		{
+			Raw:      "Node&amp;#39;s event loop",
+			Text:     "Node's event loop",
+			Testname: "TestNumericApostrophe",
+		},
+		{
+			Raw:      "Node&amp;#x27;s event loop",
+			Text:     "Node's event loop",
+			Testname: "TestHexApostrophe",
+		},
+		{
+			Raw:      "AT&amp;amp;T",
+			Text:     "AT&T",
+			Testname: "TestAmpersand",
+		},
+		{
+			Raw:      "AT&amp;T",
+			Text:     "AT&T",
+			Testname: "TestDecodedAmpersand",
+		},
+		{
+			Raw:      "&amp;quot;Hello&amp;quot; &amp;lt;world&amp;gt;",
+			Text:     `"Hello" <world>`,
+			Testname: "TestQuotesAndBrackets",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			doc := `<table><tr><td class="title"><span class="titleline"><a href="item?id=1">` + test.Raw + `</a></span></td></tr>` +
+				`<tr><td class="subtext"><span class="subline"><a href="user?id=x" class="hnuser">` + test.Raw + `</a></span></td></tr></table>`
+
+			parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Text, parsed.Title.Name)
+
+			assert.Equal(t, test.Text, parsed.Author)
+		})
+	}
+}
+
+// TestNextPage tests that the link to the next page of comments
+// is resolved against the base URL, and is absent on the last page.
+func TestNextPage(t *testing.T) {
+	base, err := url.Parse("https://news.ycombinator.com/item?id=8100000")
+
+	assert.Nil(t, err)
+
+	tests := []struct {
+		Testfile string
+		NextPage string
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample_paginated.html"),
+			NextPage: "https://news.ycombinator.com/item?id=8100000&p=2",
+			Testname: "TestPaginated",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Testname: "TestLastPage",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTMLWithBase(bytes.NewReader(sample), base)
+
+			assert.Nil(t, err)
+
+			if test.NextPage == "" {
+				assert.Nil(t, parsed.NextPage)
+
+				return
+			}
+
+			if assert.NotNil(t, parsed.NextPage) {
+				assert.Equal(t, test.NextPage, parsed.NextPage.String())
+			}
+		})
+	}
+}
+
+// TestCollapsedComments tests that collapsed comments, along
+// with the replies they hide, are still extracted.
+func TestCollapsedComments(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_collapsed.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 4, len(parsed.Comments))
+
+	assert.True(t, parsed.Comments[0].Collapsed)
+
+	assert.Equal(t, 2, parsed.Comments[0].HiddenReplies)
+
+	for _, comment := range parsed.Comments[1:] {
+		assert.False(t, comment.Collapsed)
+
+		assert.Equal(t, 0, comment.HiddenReplies)
+	}
+}
+
+// TestFadeLevel tests that the fade level of comments follows
+// the color modifier of their text.
+func TestFadeLevel(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	levels := make(map[int]int)
+
+	for _, comment := range parsed.Comments {
+		levels[comment.FadeLevel]++
+
+		assert.NotEmpty(t, comment.Content)
+	}
+
+	assert.Equal(t, map[int]int{0: 107, 1: 3, 2: 3, 4: 1, 5: 3, 6: 1}, levels)
+}
+
+// TestFadeLevelClassOrder tests that the fade level is found
+// whichever order the classes of the text are listed in.
+func TestFadeLevelClassOrder(t *testing.T) {
+	tests := []struct {
+		Class    string
+		Level    int
+		Testname string
+	}{
+		{Class: "commtext c5a", Level: 1, Testname: "TestModifierLast"},
+		{Class: "c5a commtext", Level: 1, Testname: "TestModifierFirst"},
+		{Class: "c9c  commtext", Level: 5, Testname: "TestExtraWhitespace"},
+		{Class: "commtext", Level: 0, Testname: "TestNoModifier"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			doc := `<table class="comment-tree"><tr class="athing comtr" id="3067434"><td><table><tr>` +
+				`<td class="default"><div class="comment"><div class="` + test.Class + `">Faded</div></div></td>` +
+				`</tr></table></td></tr></table>`
+
+			parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+			assert.Nil(t, err)
+
+			if assert.Len(t, parsed.Comments, 1) {
+				assert.Equal(t, test.Level, parsed.Comments[0].FadeLevel)
+			}
+		})
+	}
+}
+
+// TestStrictParse tests that a strict parser reports the fields
+// that could not be found, and that the found fields are recorded.
+func TestStrictParse(t *testing.T) {
+	strict := parser.New(parser.WithStrictParse(true))
+
+	tests := []struct {
+		Doc      string
+		Testfile string
+		Found    model.FieldsFound
+		Missing  bool
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Found:    model.FieldsFound{Title: true, ID: true, Score: true, Date: true, Author: true},
+			Testname: "TestStory",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_job.html"),
+			Found:    model.FieldsFound{Title: true, ID: true, Date: true},
+			Testname: "TestJob",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_permalink.html"),
+			Found:    model.FieldsFound{Title: true, ID: true},
+			Testname: "TestFocusedComment",
+		},
+		{
+			Doc:      `<table><tr class="athing" id="3067403"><td>unexpected</td></tr></table>`,
+			Found:    model.FieldsFound{ID: true},
+			Missing:  true,
+			Testname: "TestUnexpectedLayout",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			doc := []byte(test.Doc)
+
+			if test.Testfile != "" {
+				sample, err := os.ReadFile(test.Testfile)
+
+				assert.Nil(t, err)
+
+				doc = sample
+			}
+
+			parsed, err := strict.ParseHTML(bytes.NewReader(doc))
+
+			if test.Missing {
+				assert.ErrorIs(t, err, parser.ErrMissingFields)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			assert.Equal(t, test.Found, parsed.Found)
+
+			item, _, err := strict.ParseIndex(bytes.NewReader(doc))
+
+			if test.Missing {
+				assert.ErrorIs(t, err, parser.ErrMissingFields)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			assert.Equal(t, test.Found, item.Found)
+		})
+	}
+}
+
+// TestParseAlgoliaItem tests that an item decoded from the
+// Algolia API agrees with the same item parsed from HTML.
+func TestParseAlgoliaItem(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "algolia_item.json"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseAlgoliaItem(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	reference, err := url.Parse("https://github.com/glenjamin/node-fib")
+
+	assert.Nil(t, err)
+
+	date, err := time.Parse(dateLayout, "2011-10-03T18:32:05")
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, model.Title{Name: "Node-fib: Fast non-blocking fibonacci server", Reference: reference}, parsed.Title)
+
+	assert.Equal(t, "dchest", parsed.Author)
+
+	assert.Equal(t, 194, parsed.Points)
+
+	assert.Equal(t, 3067403, parsed.ID)
+
+	assert.Equal(t, date, parsed.Date)
+
+	var ids, depths []int
+
+	for _, comment := range parsed.Comments {
+		ids = append(ids, comment.ID)
+
+		depths = append(depths, comment.Depth)
+	}
+
+	assert.Equal(t, []int{3067434, 3067519, 3067564, 3067729}, ids)
+
+	assert.Equal(t, []int{0, 0, 1, 2}, depths)
+
+	assert.Nil(t, parsed.Comments[0].ParentID)
+
+	if assert.NotNil(t, parsed.Comments[3].ParentID) {
+		assert.Equal(t, 3067564, *parsed.Comments[3].ParentID)
+	}
+}
+
+// BenchmarkParseHTML benchmarks parsing a thread with over
+// a hundred comments.
+func BenchmarkParseHTML(b *testing.B) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(b, err)
+
+	b.ReportAllocs()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseHTML(bytes.NewReader(sample)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestExtractID tests that the ID of the item of a page is extracted
+// without parsing the page, and agrees with that of a full parse.
+func TestExtractID(t *testing.T) {
+	tests := []struct {
+		Filename string
+		Testname string
+	}{
+		{Filename: "sample1.html", Testname: "TestStory"},
+		{Filename: "sample_job.html", Testname: "TestJob"},
+		{Filename: "sample_poll.html", Testname: "TestPoll"},
+		{Filename: "sample_collapsed.html", Testname: "TestCollapsed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(filepath.Join("testdata", test.Filename))
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			id, err := parser.ExtractID(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, parsed.ID, id)
+		})
+	}
+
+	// the comments beyond the item row are never read
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	id, err := parser.New(parser.WithMaxBytes(16384)).ExtractID(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3067403, id)
+
+	notFound, err := os.ReadFile(filepath.Join("testdata", "sample_notfound.html"))
+
+	assert.Nil(t, err)
+
+	_, err = parser.ExtractID(bytes.NewReader(notFound))
+
+	assert.ErrorIs(t, err, parser.ErrItemNotFound)
+
+	_, err = parser.ExtractID(strings.NewReader(`<table><tr class="athing" id="abc"><td></td></tr></table>`))
+
+	var parseErr *parser.ParseError
+
+	if assert.ErrorAs(t, err, &parseErr) {
+		assert.Equal(t, "id", parseErr.Field)
+	}
+}
+
+// BenchmarkExtractID benchmarks extracting the ID of a thread with
+// over a hundred comments, to compare with BenchmarkParseHTML.
+func BenchmarkExtractID(b *testing.B) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(b, err)
+
+	b.ReportAllocs()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ExtractID(bytes.NewReader(sample)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseBytes(b *testing.B) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(b, err)
+
+	b.ReportAllocs()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseBytes(sample); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestParseBytes tests that parsing a byte slice agrees
+// with parsing a reader over it.
+func TestParseBytes(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	fromBytes, err := parser.ParseBytes(sample)
+
+	assert.Nil(t, err)
+
+	fromReader, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, fromReader, fromBytes)
+}
+
+// TestWithMaxComments tests that comment extraction stops at the
+// limit, and that truncation is reported, except when streaming.
+func TestWithMaxComments(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	full, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.False(t, full.CommentsTruncated)
+
+	tests := []struct {
+		MaxComments int
+		ReplyCounts []int
+		Truncated   bool
+		Testname    string
+	}{
+		{
+			MaxComments: 5,
+			ReplyCounts: []int{0, 1, 1, 1, 0},
+			Truncated:   true,
+			Testname:    "TestBelowCount",
+		},
+		{
+			MaxComments: 118,
+			Truncated:   false,
+			Testname:    "TestAtCount",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			limited, err := parser.New(parser.WithMaxComments(test.MaxComments)).ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			expected := slices.Clone(full.Comments[:test.MaxComments])
+
+			// only the replies that were kept are counted
+			for i, count := range test.ReplyCounts {
+				expected[i].ReplyCount = count
+			}
+
+			assert.Equal(t, expected, limited.Comments)
+
+			assert.Equal(t, test.Truncated, limited.CommentsTruncated)
+
+			item, comments, errs := parser.New(parser.WithMaxComments(test.MaxComments)).ParseHTMLStream(context.Background(), bytes.NewReader(sample))
+
+			var streamed int
+
+			for range comments {
+				streamed++
+			}
+
+			assert.Nil(t, <-errs)
+
+			assert.Equal(t, test.MaxComments, streamed)
+
+			// the item is returned before the limit
+			// is reached, so it is never marked
+			assert.False(t, item.CommentsTruncated)
+		})
+	}
+}
+
+// TestWithMaxDepth tests that comments deeper than the maximum depth
+// are skipped, marking the comments as truncated when any were.
+func TestWithMaxDepth(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	full, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	tests := []struct {
+		MaxDepth  int
+		Truncated bool
+		Testname  string
+	}{
+		{
+			MaxDepth:  0,
+			Truncated: true,
+			Testname:  "TestTopLevel",
+		},
+		{
+			MaxDepth:  1,
+			Truncated: true,
+			Testname:  "TestDirectReplies",
+		},
+		{
+			MaxDepth:  100,
+			Truncated: false,
+			Testname:  "TestBeyondDeepest",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			var expected []model.Comment
+
+			for _, comment := range full.Comments {
+				// the replies beyond the maximum depth are not counted
+				if comment.Depth == test.MaxDepth {
+					comment.ReplyCount = 0
+				}
+
+				if comment.Depth <= test.MaxDepth {
+					expected = append(expected, comment)
+				}
+			}
+
+			limited, err := parser.New(parser.WithMaxDepth(test.MaxDepth)).ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, expected, limited.Comments)
+
+			assert.Equal(t, test.Truncated, limited.CommentsTruncated)
+		})
+	}
+
+	// the top-level comments of the thread number fewer than the whole
+	limited, err := parser.New(parser.WithMaxDepth(0)).ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, full.TopLevelCommentCount, len(limited.Comments))
+
+	assert.Less(t, len(limited.Comments), len(full.Comments))
+}
+
+// TestOnStory tests that the story a comment was made on is
+// extracted from comments shown outside of their thread.
+func TestOnStory(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_threads.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, len(parsed.Comments))
+
+	if assert.NotNil(t, parsed.Comments[0].StoryID) {
+		assert.Equal(t, 3067403, *parsed.Comments[0].StoryID)
+	}
+
+	assert.Equal(t, "Node-fib: Fast non-blocking fibonacci server", parsed.Comments[0].StoryTitle)
+
+	assert.Nil(t, parsed.Comments[1].StoryID)
+
+	assert.Equal(t, "", parsed.Comments[1].StoryTitle)
+
+	if assert.NotNil(t, parsed.Comments[2].StoryID) {
+		assert.Equal(t, 3061000, *parsed.Comments[2].StoryID)
+	}
+
+	assert.Equal(t, "Writing less code", parsed.Comments[2].StoryTitle)
+}
+
+// TestErrorPages tests that the pages HN serves in place of
+// the requested item are reported as such.
+func TestErrorPages(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Err      error
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample_ratelimited.html"),
+			Err:      parser.ErrRateLimited,
+			Testname: "TestRateLimited",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_validation.html"),
+			Err:      parser.ErrValidationRequired,
+			Testname: "TestValidationRequired",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_notfound.html"),
+			Err:      parser.ErrItemNotFound,
+			Testname: "TestItemNotFound",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_threads.html"),
+			Testname: "TestThreads",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			_, err = parser.ParseHTML(bytes.NewReader(sample))
+
+			if test.Err == nil {
+				assert.Nil(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, test.Err)
+		})
+	}
+}
+
+// TestOpenGraph tests that the Open Graph metadata of a proxied
+// page is extracted, and that HN pages are left without it.
+func TestOpenGraph(t *testing.T) {
+	base, err := url.Parse("https://mirror.example.com/item?id=8100000")
+
+	assert.Nil(t, err)
+
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_opengraph.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTMLWithBase(bytes.NewReader(sample), base)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "Today we are very proud to announce the 1.0 release of Rust.", parsed.Description)
+
+	if assert.NotNil(t, parsed.ImageURL) {
+		assert.Equal(t, "https://mirror.example.com/static/images/rust-logo.png", parsed.ImageURL.String())
+	}
+
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "", parsed.Description)
+
+	assert.Nil(t, parsed.ImageURL)
+}
+
+// TestWithoutComments tests that skipping the comments
+// leaves the item metadata intact.
+func TestWithoutComments(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	full, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.New(parser.WithComments(false)).ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, parsed.Comments)
+
+	// the participants are derived from the comments
+	full.Comments = nil
+	full.Participants = nil
+	full.TopLevelCommentCount = 0
+
+	assert.Equal(t, full, parsed)
+}
+
+// BenchmarkParseHTMLWithoutComments benchmarks parsing a thread
+// with over a hundred comments while skipping the comments.
+func BenchmarkParseHTMLWithoutComments(b *testing.B) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(b, err)
+
+	p := parser.New(parser.WithComments(false))
+
+	b.ReportAllocs()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ParseHTML(bytes.NewReader(sample)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestCommentError tests that errors while extracting a comment
+// identify the comment they occurred in.
+func TestCommentError(t *testing.T) {
+	doc := `<table class="comment-tree"><tr class="athing comtr" id="3067434"><td><table><tr><td class="default">` +
+		`<span class="comhead"><span class="age" title="yesterday"></span></span></td></tr></table></td></tr></table>`
+
+	_, err := parser.ParseHTML(strings.NewReader(doc))
+
+	var commentErr *parser.CommentError
+
+	if assert.True(t, errors.As(err, &commentErr)) {
+		assert.Equal(t, 3067434, commentErr.ID)
+	}
+
+	var parseErr *parser.ParseError
+
+	if assert.True(t, errors.As(err, &parseErr)) {
+		assert.Equal(t, "date", parseErr.Field)
+
+		assert.Equal(t, "yesterday", parseErr.Raw)
+	}
+}
+
+// TestWithClassNames tests that extraction follows a class
+// that was renamed by overriding its class name.
+func TestWithClassNames(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	expected, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	renamed := bytes.ReplaceAll(sample, []byte(`class="hnuser"`), []byte(`class="hnuser-v2"`))
+
+	stale, err := parser.ParseHTML(bytes.NewReader(renamed))
+
+	assert.Nil(t, err)
+
+	assert.Empty(t, stale.Author)
+
+	classes := parser.DefaultClassNames()
+
+	classes.User = "hnuser-v2"
+
+	parsed, err := parser.New(parser.WithClassNames(classes)).ParseHTML(bytes.NewReader(renamed))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, expected.Author, parsed.Author)
+
+	assert.Equal(t, expected.Comments[0].Author, parsed.Comments[0].Author)
+}
+
+// TestWithClassNamesStructure tests that the classes of the structure of
+// the page, beyond those of its fields, may be renamed as well.
+func TestWithClassNamesStructure(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Class    string
+		Renamed  string
+		Rename   func(classes *parser.ClassNames, name string)
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Class:    "title",
+			Renamed:  "title-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.Title = name },
+			Testname: "TestTitle",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Class:    "ind",
+			Renamed:  "ind-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.Indent = name },
+			Testname: "TestIndent",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Class:    "default",
+			Renamed:  "default-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.CommentCell = name },
+			Testname: "TestCommentCell",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_loggedin.html"),
+			Class:    "reply",
+			Renamed:  "reply-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.Reply = name },
+			Testname: "TestReply",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Class:    "navs",
+			Renamed:  "navs-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.Navs = name },
+			Testname: "TestNavs",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_collapsed.html"),
+			Class:    "togg clicky",
+			Renamed:  "togg-v2 clicky",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.Toggle = name },
+			Testname: "TestToggle",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_ask.html"),
+			Class:    "fatitem",
+			Renamed:  "fatitem-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.FatItem = name },
+			Testname: "TestFatItem",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_ask.html"),
+			Class:    "toptext",
+			Renamed:  "toptext-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.TopText = name },
+			Testname: "TestTopText",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_favicon.html"),
+			Class:    "votelinks",
+			Renamed:  "votelinks-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.VoteLinks = name },
+			Testname: "TestVoteLinks",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Class:    "subline",
+			Renamed:  "subline-v2",
+			Rename:   func(classes *parser.ClassNames, name string) { classes.Subline = name },
+			Testname: "TestSubline",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			expected, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			classes := parser.DefaultClassNames()
+
+			test.Rename(&classes, test.Renamed)
+
+			renamed := sample
+
+			// the attributes are quoted either way
+			for _, quote := range []string{`"`, `'`} {
+				renamed = bytes.ReplaceAll(renamed, []byte(`class=`+quote+test.Class+quote), []byte(`class=`+quote+test.Renamed+quote))
+			}
+
+			assert.NotEqual(t, sample, renamed)
+
+			stale, _ := parser.ParseHTML(bytes.NewReader(renamed))
+
+			assert.NotEqual(t, expected, stale)
+
+			parsed, err := parser.New(parser.WithClassNames(classes)).ParseHTML(bytes.NewReader(renamed))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, expected, parsed)
+		})
+	}
+}
+
+// TestSecondaryLinks tests that the links of the title beyond the
+// main one are extracted, skipping the link to the site.
+func TestSecondaryLinks(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_show.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, model.KindShow, parsed.Kind)
+
+	assert.Equal(t, "Show HN: Tinyq, a durable job queue in 300 lines of Go", parsed.Title.Name)
+
+	assert.Equal(t, "https://github.com/queuesmith/tinyq", parsed.Title.Reference.String())
+
+	assert.Equal(t, "github.com/queuesmith", parsed.Domain)
+
+	if assert.Len(t, parsed.SecondaryLinks, 1) {
+		assert.Equal(t, "https://tinyq.dev/demo?utm_source=hn", parsed.SecondaryLinks[0].String())
+	}
+
+	// the secondary links are sanitized along with the main one
+	parsed, err = parser.New(parser.WithStripTrackingParams(true)).ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	if assert.Len(t, parsed.SecondaryLinks, 1) {
+		assert.Equal(t, "https://tinyq.dev/demo", parsed.SecondaryLinks[0].String())
+	}
+
+	// a title with a single link has none
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err = parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Nil(t, parsed.SecondaryLinks)
+}
+
+// TestStripTrackingParams tests that the tracking parameters of a URL
+// are stripped, keeping the order and the encoding of the others.
+func TestStripTrackingParams(t *testing.T) {
+	tests := []struct {
+		Raw      string
+		Expected string
+		Testname string
+	}{
+		{
+			Raw:      "https://example.com/post?page=2&utm_source=hn&utm_medium=social&ref=hn",
+			Expected: "https://example.com/post?page=2",
+			Testname: "TestUTMAndRef",
+		},
+		{
+			Raw:      "https://example.com/post?fbclid=abc&z=1&a=%2F#comments",
+			Expected: "https://example.com/post?z=1&a=%2F#comments",
+			Testname: "TestKeepsOrder",
+		},
+		{
+			Raw:      "https://example.com/post?UTM_CAMPAIGN=spring",
+			Expected: "https://example.com/post",
+			Testname: "TestCaseInsensitive",
+		},
+		{
+			Raw:      "https://example.com/post?reference=1&id=7",
+			Expected: "https://example.com/post?reference=1&id=7",
+			Testname: "TestNoTracking",
+		},
+		{
+			Raw:      "item?id=3067403",
+			Expected: "item?id=3067403",
+			Testname: "TestRelative",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			raw, err := url.Parse(test.Raw)
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Expected, parser.StripTrackingParams(raw).String())
+
+			// the provided URL is left untouched
+			assert.Equal(t, test.Raw, raw.String())
+		})
+	}
+
+	assert.Nil(t, parser.StripTrackingParams(nil))
+}
+
+// TestWithURLSanitizer tests that the reference of the title and the
+// links of the comments are rewritten by the URL sanitizer, if any,
+// and stripped of tracking parameters, whatever the order of options.
+func TestWithURLSanitizer(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_tracking.html"))
+
+	assert.Nil(t, err)
+
+	httpSanitizer := func(u *url.URL) *url.URL {
+		sanitized := *u
+		sanitized.Scheme = "http"
+
+		return &sanitized
+	}
+
+	tests := []struct {
+		Parser    *parser.Parser
+		Reference string
+		Links     [][]string
+		Testname  string
+	}{
+		{
+			Parser:    parser.New(),
+			Reference: "https://example.com/posts/query-planning?page=2&utm_source=hackernews&utm_medium=social&ref=hn",
+			Links: [][]string{
+				{"https://example.org/planner?fbclid=IwAR0xyz&section=joins"},
+				{"https://example.net/bench?UTM_CAMPAIGN=spring", "https://example.net/bench"},
+			},
+			Testname: "TestDefault",
+		},
+		{
+			Parser:    parser.New(parser.WithStripTrackingParams(true)),
+			Reference: "https://example.com/posts/query-planning?page=2",
+			Links: [][]string{
+				{"https://example.org/planner?section=joins"},
+				{"https://example.net/bench"},
+			},
+			Testname: "TestStripTrackingParams",
+		},
+		{
+			Parser: parser.New(parser.WithURLSanitizer(func(u *url.URL) *url.URL {
+				if u.Host == "example.org" {
+					return nil
+				}
+
+				sanitized := *u
+				sanitized.Scheme = "http"
+
+				return &sanitized
+			})),
+			Reference: "http://example.com/posts/query-planning?page=2&utm_source=hackernews&utm_medium=social&ref=hn",
+			Links: [][]string{
+				nil,
+				{"http://example.net/bench?UTM_CAMPAIGN=spring", "http://example.net/bench"},
+			},
+			Testname: "TestCustomSanitizer",
+		},
+		{
+			Parser: parser.New(
+				parser.WithStripTrackingParams(true),
+				parser.WithStripTrackingParams(false),
+			),
+			Reference: "https://example.com/posts/query-planning?page=2&utm_source=hackernews&utm_medium=social&ref=hn",
+			Links: [][]string{
+				{"https://example.org/planner?fbclid=IwAR0xyz&section=joins"},
+				{"https://example.net/bench?UTM_CAMPAIGN=spring", "https://example.net/bench"},
+			},
+			Testname: "TestDisabled",
+		},
+		{
+			Parser: parser.New(
+				parser.WithURLSanitizer(parser.StripTrackingParams),
+				parser.WithStripTrackingParams(false),
+			),
+			Reference: "https://example.com/posts/query-planning?page=2",
+			Links: [][]string{
+				{"https://example.org/planner?section=joins"},
+				{"https://example.net/bench"},
+			},
+			Testname: "TestDisabledKeepsSanitizer",
+		},
+		{
+			Parser: parser.New(
+				parser.WithStripTrackingParams(true),
+				parser.WithURLSanitizer(httpSanitizer),
+			),
+			Reference: "http://example.com/posts/query-planning?page=2",
+			Links: [][]string{
+				{"http://example.org/planner?section=joins"},
+				{"http://example.net/bench"},
+			},
+			Testname: "TestStrippedThenSanitized",
+		},
+		{
+			Parser: parser.New(
+				parser.WithURLSanitizer(httpSanitizer),
+				parser.WithStripTrackingParams(true),
+			),
+			Reference: "http://example.com/posts/query-planning?page=2",
+			Links: [][]string{
+				{"http://example.org/planner?section=joins"},
+				{"http://example.net/bench"},
+			},
+			Testname: "TestSanitizedThenStripped",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			parsed, err := test.Parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Reference, parsed.Title.Reference.String())
+
+			var links [][]string
+
+			for _, comment := range parsed.Comments {
+				links = append(links, comment.Links)
+			}
+
+			assert.Equal(t, test.Links, links)
+		})
+	}
+}
+
+// TestWithMaxBytes tests that documents larger than the limit
+// are rejected, and that documents within it parse as usual.
+func TestWithMaxBytes(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	expected, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	size := int64(len(sample))
+
+	tests := []struct {
+		MaxBytes int64
+		TooLarge bool
+		Testname string
+	}{
+		{MaxBytes: 0, TooLarge: false, Testname: "TestNoLimit"},
+		{MaxBytes: size + 1, TooLarge: false, Testname: "TestUnderLimit"},
+		{MaxBytes: size, TooLarge: false, Testname: "TestAtLimit"},
+		{MaxBytes: size - 1, TooLarge: true, Testname: "TestOverLimit"},
+		{MaxBytes: 1024, TooLarge: true, Testname: "TestFarOverLimit"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			p := parser.New(parser.WithMaxBytes(test.MaxBytes))
+
+			parsed, err := p.ParseHTML(bytes.NewReader(sample))
+
+			if test.TooLarge {
+				assert.ErrorIs(t, err, parser.ErrDocumentTooLarge)
+
+				assert.Nil(t, parsed)
+
+				_, err = p.ParseListHTML(bytes.NewReader(sample))
+
+				assert.ErrorIs(t, err, parser.ErrDocumentTooLarge)
+
+				return
+			}
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, expected, parsed)
+		})
+	}
+}
+
+// TestWithLenientDates tests that a lenient parser records the
+// raw dates that cannot be parsed, rather than failing, and
+// keeps the other comments of the thread.
+func TestWithLenientDates(t *testing.T) {
+	doc := `<table class="fatitem"><tr class="athing" id="3067403"><td class="title"><span class="titleline">` +
+		`<a href="x">x</a></span></td></tr><tr><td class="subtext"><span class="subline">` +
+		`<span class="age" title="sometime"><a href="item?id=3067403">a while ago</a></span></span></td></tr></table>` +
+		`<table class="comment-tree"><tr class="athing comtr" id="3067434"><td><table><tr><td class="default">` +
+		`<span class="comhead"><span class="age" title="yesterday"></span></span></td></tr></table></td></tr>` +
+		`<tr class="athing comtr" id="3067519"><td><table><tr><td class="default">` +
+		`<span class="comhead"><span class="age" title="2011-10-03T19:31:38 1317670298"></span></span></td></tr>` +
+		`</table></td></tr></table>`
+
+	_, err := parser.ParseHTML(strings.NewReader(doc))
+
+	assert.NotNil(t, err)
+
+	parsed, err := parser.New(parser.WithLenientDates(true)).ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.True(t, parsed.Date.IsZero())
+
+	assert.Equal(t, "sometime", parsed.RawDate)
+
+	assert.False(t, parsed.Found.Date)
+
+	if assert.Len(t, parsed.Comments, 2) {
+		assert.True(t, parsed.Comments[0].Date.IsZero())
+
+		assert.Equal(t, "yesterday", parsed.Comments[0].RawDate)
+
+		assert.Equal(t, time.Date(2011, time.October, 3, 19, 31, 38, 0, time.UTC), parsed.Comments[1].Date)
+
+		assert.Empty(t, parsed.Comments[1].RawDate)
+	}
+}
+
+// TestWithStats tests that the statistics of the parsed documents are
+// accumulated, including the errors encountered, whether they fail the
+// parse or are tolerated.
+func TestWithStats(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	stats := &parser.Stats{}
+
+	p := parser.New(parser.WithStats(stats))
+
+	parsed, err := p.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, stats.Documents)
+
+	assert.Equal(t, len(parsed.Comments), stats.CommentsExtracted)
+
+	assert.Positive(t, stats.NodesVisited)
+
+	assert.Positive(t, stats.Duration)
+
+	assert.Empty(t, stats.Errors)
+
+	visited := stats.NodesVisited
+
+	_, err = p.ParseBytes(sample)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, stats.Documents)
+
+	assert.Equal(t, 2*len(parsed.Comments), stats.CommentsExtracted)
+
+	assert.Equal(t, 2*visited, stats.NodesVisited)
+
+	notFound, err := os.ReadFile(filepath.Join("testdata", "sample_notfound.html"))
+
+	assert.Nil(t, err)
+
+	_, err = p.ParseHTML(bytes.NewReader(notFound))
+
+	assert.ErrorIs(t, err, parser.ErrItemNotFound)
+
+	assert.Equal(t, map[string]int{"other": 1}, stats.Errors)
+
+	// a date that fails the parse and the dates tolerated
+	// by a lenient Parser are counted alike
+	doc := `<table class="fatitem"><tr class="athing" id="3067403"><td class="title"><span class="titleline">` +
+		`<a href="x">x</a></span></td></tr><tr><td class="subtext"><span class="subline">` +
+		`<span class="age" title="sometime"><a href="item?id=3067403">a while ago</a></span></span></td></tr></table>` +
+		`<table class="comment-tree"><tr class="athing comtr" id="3067434"><td><table><tr><td class="default">` +
+		`<span class="comhead"><span class="age" title="yesterday"></span></span></td></tr></table></td></tr></table>`
+
+	stats = &parser.Stats{}
+
+	_, err = parser.New(parser.WithStats(stats)).ParseHTML(strings.NewReader(doc))
+
+	assert.NotNil(t, err)
+
+	assert.Equal(t, map[string]int{"date": 1}, stats.Errors)
+
+	_, err = parser.New(parser.WithStats(stats), parser.WithLenientDates(true)).ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, stats.Documents)
+
+	assert.Equal(t, 1, stats.CommentsExtracted)
+
+	assert.Equal(t, map[string]int{"date": 3}, stats.Errors)
+}
+
+// TestWithLogger tests that the logger is notified of the fields skipped for
+// lack of an expected node, and of the tolerated dates, and only of those.
+func TestWithLogger(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Lenient  bool
+		Logs     []string
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Testname: "TestNoDrift",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_poll_subline.html"),
+			Testname: "TestPollOptionSublines",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_drift.html"),
+			Logs: []string{
+				`score: subline has no "score" node`,
+				`comment: comment 8800020 has no "default" cell`,
+			},
+			Testname: "TestDrift",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_lenient.html"),
+			Lenient:  true,
+			Logs: []string{
+				`date: tolerated unparsable date "sometime"`,
+				`date: tolerated unparsable date "yesterday"`,
+			},
+			Testname: "TestToleratedDates",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			var logs []string
+
+			p := parser.New(parser.WithLenientDates(test.Lenient), parser.WithLogger(func(field string, reason string) {
+				logs = append(logs, field+": "+reason)
+			}))
+
+			_, err = p.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, test.Logs, logs)
+		})
+	}
+}
+
+// TestFavicon tests that the favicon of the site of an item is extracted
+// from the vote cell, or wherever it carries the class of that cell, and
+// that neither the spacer image of a missing vote arrow nor the image of
+// a vote arrow is mistaken for one.
+func TestFavicon(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Favicon  string
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample_favicon.html"),
+			Favicon:  "https://news.ycombinator.com/favicon?site=example.com",
+			Testname: "TestVoteCell",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample1.html"),
+			Testname: "TestNoFavicon",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_grayarrow.html"),
+			Testname: "TestGrayArrow",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_permalink.html"),
+			Testname: "TestCommentPage",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			if test.Favicon == "" {
+				assert.Nil(t, parsed.FaviconURL)
+
+				return
+			}
+
+			if assert.NotNil(t, parsed.FaviconURL) {
+				assert.Equal(t, test.Favicon, parsed.FaviconURL.String())
+			}
+		})
+	}
+
+	// the favicon may also lie outside the vote cell, carrying its class
+	doc := `<table class="fatitem"><tr class="athing" id="9000100"><td class="votelinks">` +
+		`<center><img src="s.gif" height="1" width="14"></center></td><td><img class="votelinks" src="https://example.com/favicon.ico"></td>` +
+		`<td class="title"><span class="titleline"><a href="https://example.com">x</a></span></td></tr></table>`
+
+	parsed, err := parser.ParseHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	if assert.NotNil(t, parsed.FaviconURL) {
+		assert.Equal(t, "https://example.com/favicon.ico", parsed.FaviconURL.String())
+	}
 }
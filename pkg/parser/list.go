@@ -0,0 +1,181 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"context"
+	"io"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"golang.org/x/net/html"
+)
+
+// ParseListHTML parses a listing page, such as the front page, from the provided
+// io.Reader and returns one model.Item per listed item, in the order in which they
+// are ranked. ParseListHTML uses a Parser with the default options.
+func ParseListHTML(doc io.Reader) ([]model.Item, error) {
+	return defaultParser.ParseListHTML(doc)
+}
+
+// ParseList behaves like ParseListHTML, but periodically checks the provided
+// context while extracting the listed items and returns the context's error as
+// soon as it is cancelled or its deadline is exceeded. ParseList uses a Parser
+// with the default options.
+func ParseList(ctx context.Context, doc io.Reader) ([]model.Item, error) {
+	return defaultParser.ParseList(ctx, doc)
+}
+
+// ParseListing behaves like ParseList, but returns a model.Listing, which also
+// reports whether the cutoff set by WithSince was reached. ParseListing uses a
+// Parser with the default options.
+func ParseListing(ctx context.Context, doc io.Reader) (*model.Listing, error) {
+	return defaultParser.ParseListing(ctx, doc)
+}
+
+// ParseListHTML parses a listing page, such as the front page, from the provided
+// io.Reader and returns one model.Item per listed item, in the order in which they
+// are ranked. Each item is populated with the rank, ID, title, and domain held by
+// its "athing" row, along with the score, author, date, and comment count held by
+// the subline of the row that follows it. Listing pages carry no comments, so the
+// comments of each item are left empty. When the Parser was configured with a cutoff
+// by WithSince, the items from the first one older than the cutoff are silently left
+// out; ParseListing is required to tell whether the cutoff was reached. Returns an
+// error if the document cannot be parsed, or if any of the rows cannot be extracted.
+func (p *Parser) ParseListHTML(doc io.Reader) ([]model.Item, error) {
+	return p.ParseList(context.Background(), doc)
+}
+
+// ParseList behaves like ParseListHTML, but periodically checks the provided
+// context while extracting the listed items and returns the context's error as
+// soon as it is cancelled or its deadline is exceeded. It handles each of the
+// front, newest, ask, show, and jobs listings, whose job postings carry a date
+// but neither a score, an author, nor a comment count. As with ParseListHTML, the
+// cutoff set by WithSince truncates the items silently; use ParseListing to tell
+// whether it was reached.
+func (p *Parser) ParseList(ctx context.Context, doc io.Reader) ([]model.Item, error) {
+	listing, err := p.ParseListing(ctx, doc)
+
+	if listing == nil {
+		return nil, err
+	}
+
+	return listing.Items, err
+}
+
+// ParseListing behaves like ParseList, but returns a model.Listing. When the Parser
+// was configured with a cutoff by WithSince, extraction stops at the first item
+// older than the cutoff, which is left out, and the listing reports that the cutoff
+// was reached. Items whose date is unknown never reach the cutoff. On error, the
+// listing holds the items extracted so far, and is nil if the document could not
+// be parsed.
+func (p *Parser) ParseListing(ctx context.Context, doc io.Reader) (listing *model.Listing, err error) {
+	defer recoverPanic(&err)
+
+	node, err := p.parseDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkErrorPage(node); err != nil {
+		return nil, err
+	}
+
+	listing = &model.Listing{}
+
+	var rows []*html.Node
+
+	traverseNode(node, func(n *html.Node) {
+		if p.isListRow(n) {
+			rows = append(rows, n)
+		}
+	})
+
+	var visited int
+
+	for _, row := range rows {
+		var item model.Item
+
+		if err := p.walkNode(ctx, row, &item, NopVisitor{}, &visited); err != nil {
+			return listing, err
+		}
+
+		// the subline lies in a sibling row
+		if subtextRow := p.getSubtextRow(row); subtextRow != nil {
+			if err := p.walkNode(ctx, subtextRow, &item, NopVisitor{}, &visited); err != nil {
+				return listing, err
+			}
+		}
+
+		if !p.since.IsZero() && !item.Date.IsZero() && item.Date.Before(p.since) {
+			listing.CutoffReached = true
+
+			break
+		}
+
+		inferKind(&item)
+
+		listing.Items = append(listing.Items, item)
+	}
+
+	return listing, nil
+}
+
+// getSubtextRow returns the row holding the subtext of the provided item row on a
+// listing page, which follows it as a sibling rather than being nested within it.
+// The search stops at the next item row or spacer, so that an item lacking its
+// subtext never borrows that of the item below it. Returns nil if no such row is
+// found.
+func (p *Parser) getSubtextRow(row *html.Node) *html.Node {
+	for sibling := row.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+
+		if p.isListRow(sibling) || classIs(sibling, p.classes.Spacer) {
+			return nil
+		}
+
+		if hasChildClass(sibling, p.classes.Subtext) {
+			return sibling
+		}
+	}
+
+	return nil
+}
+
+// isListRow checks whether the provided HTML node is the row of an item on a
+// listing page, as opposed to the rows of comments and poll options.
+func (p *Parser) isListRow(node *html.Node) bool {
+	return node.Type == html.ElementNode && p.isItemRow(node)
+}
@@ -0,0 +1,324 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseListHTML tests that each row of a listing is
+// paired with its subtext.
+func TestParseListHTML(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_news.html"))
+
+	assert.Nil(t, err)
+
+	items, err := parser.ParseListHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Len(t, items, 5)
+
+	tests := []struct {
+		Rank         int
+		ID           int
+		Title        string
+		Domain       string
+		Points       int
+		Author       string
+		Date         time.Time
+		CommentCount int
+		IsJob        bool
+		Kind         model.Kind
+		Testname     string
+	}{
+		{
+			Rank:         1,
+			ID:           41500001,
+			Title:        "A new approach to incremental compilation",
+			Domain:       "example.edu",
+			Points:       412,
+			Author:       "compilerfan",
+			Date:         time.Date(2024, time.September, 10, 8, 14, 22, 0, time.UTC),
+			CommentCount: 128,
+			IsJob:        false,
+			Kind:         model.KindStory,
+			Testname:     "TestStory",
+		},
+		{
+			Rank:         2,
+			ID:           41500002,
+			Title:        "Ask HN: What are you working on this month?",
+			Domain:       "",
+			Points:       96,
+			Author:       "monthlythread",
+			Date:         time.Date(2024, time.September, 10, 6, 0, 3, 0, time.UTC),
+			CommentCount: 214,
+			IsJob:        false,
+			Kind:         model.KindAsk,
+			Testname:     "TestSelfPost",
+		},
+		{
+			Rank:         3,
+			ID:           41500003,
+			Title:        "Sandstorm (YC S14) is hiring a founding engineer",
+			Domain:       "ycombinator.com",
+			Points:       0,
+			Author:       "",
+			Date:         time.Date(2024, time.September, 10, 5, 0, 51, 0, time.UTC),
+			CommentCount: 0,
+			IsJob:        true,
+			Kind:         model.KindJob,
+			Testname:     "TestJobPosting",
+		},
+		{
+			Rank:         4,
+			ID:           41500004,
+			Title:        "Show HN: A tiny Forth interpreter in 512 bytes",
+			Domain:       "github.com/example",
+			Points:       57,
+			Author:       "forthright",
+			Date:         time.Date(2024, time.September, 10, 4, 41, 37, 0, time.UTC),
+			CommentCount: 1,
+			IsJob:        false,
+			Kind:         model.KindShow,
+			Testname:     "TestShowHN",
+		},
+		{
+			Rank:         5,
+			ID:           41500005,
+			Title:        "The history of the semicolon",
+			Domain:       "example.org",
+			Points:       3,
+			Author:       "punctuation",
+			Date:         time.Date(2024, time.September, 10, 9, 59, 12, 0, time.UTC),
+			CommentCount: 0,
+			IsJob:        false,
+			Kind:         model.KindStory,
+			Testname:     "TestNewStory",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			item := items[i]
+
+			assert.Equal(t, test.Rank, item.Rank)
+
+			assert.Equal(t, test.ID, item.ID)
+
+			assert.Equal(t, test.Title, item.Title.Name)
+
+			assert.Equal(t, test.Domain, item.Domain)
+
+			assert.Equal(t, test.Points, item.Points)
+
+			assert.Equal(t, test.Author, item.Author)
+
+			assert.Equal(t, test.Date, item.Date)
+
+			assert.Equal(t, test.CommentCount, item.CommentCount)
+
+			assert.Equal(t, test.IsJob, item.IsJob)
+
+			assert.Equal(t, test.Kind, item.Kind)
+
+			// job postings cannot be voted on
+			assert.Equal(t, !test.IsJob, item.Voteable)
+
+			assert.Empty(t, item.Comments)
+		})
+	}
+}
+
+// TestParseListHTMLItemPage tests that the page of an item
+// lists the item alone.
+func TestParseListHTMLItemPage(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	items, err := parser.ParseListHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	// only the story itself is listed on its own page,
+	// without a rank
+	assert.Len(t, items, 1)
+
+	assert.Equal(t, 0, items[0].Rank)
+
+	assert.Empty(t, items[0].Comments)
+}
+
+// TestParseListJobs tests that job postings are listed
+// with their dates.
+func TestParseListJobs(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_jobs.html"))
+
+	assert.Nil(t, err)
+
+	items, err := parser.ParseList(context.Background(), bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Len(t, items, 3)
+
+	for i, item := range items {
+		assert.Equal(t, i+1, item.Rank)
+
+		assert.True(t, item.IsJob)
+
+		assert.True(t, item.Found.Date)
+
+		assert.Equal(t, "", item.Author)
+	}
+
+	assert.Equal(t, 41600002, items[1].ID)
+
+	assert.Equal(t, "Lumen Labs (YC W21) Is Hiring Rust Engineers", items[1].Title.Name)
+
+	assert.Equal(t, time.Date(2024, time.September, 11, 12, 0, 40, 0, time.UTC), items[1].Date)
+}
+
+// TestParseListMissingSubtext tests that a row without a
+// subtext does not take that of the next row.
+func TestParseListMissingSubtext(t *testing.T) {
+	doc := `<table>
+		<tr class="athing" id="1"><td class="title"><span class="rank">1.</span></td></tr>
+		<tr class="athing" id="2"><td class="title"><span class="rank">2.</span></td></tr>
+		<tr><td class="subtext"><span class="subline"><span class="score">5 points</span></span></td></tr>
+	</table>`
+
+	items, err := parser.ParseList(context.Background(), strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Len(t, items, 2)
+
+	// the first item must not borrow the
+	// subtext of the second one
+	assert.False(t, items[0].Found.Score)
+
+	assert.Equal(t, 5, items[1].Points)
+}
+
+// TestParseListCancelled tests that parsing stops with the
+// context's error once the context is cancelled.
+func TestParseListCancelled(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_news.html"))
+
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items, err := parser.ParseList(ctx, bytes.NewReader(sample))
+
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Nil(t, items)
+}
+
+// TestWithSince tests that parsing a listing stops at the
+// first item older than the cutoff, and reports it.
+func TestWithSince(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_news.html"))
+
+	assert.Nil(t, err)
+
+	tests := []struct {
+		Since         time.Time
+		IDs           []int
+		CutoffReached bool
+		Testname      string
+	}{
+		{
+			Since:         time.Time{},
+			IDs:           []int{41500001, 41500002, 41500003, 41500004, 41500005},
+			CutoffReached: false,
+			Testname:      "TestNoCutoff",
+		},
+		{
+			Since:         time.Date(2024, time.September, 10, 5, 30, 0, 0, time.UTC),
+			IDs:           []int{41500001, 41500002},
+			CutoffReached: true,
+			Testname:      "TestCutoff",
+		},
+		{
+			Since:         time.Date(2024, time.September, 10, 9, 0, 0, 0, time.UTC),
+			IDs:           nil,
+			CutoffReached: true,
+			Testname:      "TestCutoffAtFirstItem",
+		},
+		{
+			Since:         time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC),
+			IDs:           []int{41500001, 41500002, 41500003, 41500004, 41500005},
+			CutoffReached: false,
+			Testname:      "TestCutoffNotReached",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			p := parser.New(parser.WithSince(test.Since))
+
+			listing, err := p.ParseListing(context.Background(), bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			var ids []int
+
+			for _, item := range listing.Items {
+				ids = append(ids, item.ID)
+			}
+
+			assert.Equal(t, test.IDs, ids)
+
+			assert.Equal(t, test.CutoffReached, listing.CutoffReached)
+
+			items, err := p.ParseListHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, listing.Items, items)
+		})
+	}
+}
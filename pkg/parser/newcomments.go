@@ -0,0 +1,97 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"io"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"golang.org/x/net/html"
+)
+
+// ParseNewCommentsHTML parses the newest comments across all stories, as listed by
+// the "newcomments" page, from the provided io.Reader. ParseNewCommentsHTML uses a
+// Parser with the default options.
+func ParseNewCommentsHTML(doc io.Reader) ([]model.Comment, error) {
+	return defaultParser.ParseNewCommentsHTML(doc)
+}
+
+// ParseNewCommentsHTML parses the newest comments across all stories, as listed by
+// the "newcomments" page, from the provided io.Reader, and returns them in document
+// order. Unlike the comments of a thread, those of the feed are not nested, and each
+// of them links to the story it was made on, which is recorded in its StoryID and
+// StoryTitle, and to its parent, which is recorded in its ParentID. Returns an error
+// if the document cannot be parsed, if it is a page that HN serves in place of the
+// requested page, or if any of the comments cannot be extracted.
+func (p *Parser) ParseNewCommentsHTML(doc io.Reader) (comments []model.Comment, err error) {
+	defer recoverPanic(&err)
+
+	node, err := p.parseDocument(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.checkErrorPage(node); err != nil {
+		return nil, err
+	}
+
+	var rows []*html.Node
+
+	traverseNode(node, func(n *html.Node) {
+		if p.isCommentRow(n) {
+			rows = append(rows, n)
+		}
+	})
+
+	shown := make(map[int]bool)
+
+	for _, row := range rows {
+		comment, err := p.extractComment(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if comment == nil {
+			continue
+		}
+
+		comments = append(comments, *comment)
+
+		if p.replyCountNode(row) != nil {
+			shown[comment.ID] = true
+		}
+	}
+
+	countReplies(comments, shown)
+
+	return comments, nil
+}
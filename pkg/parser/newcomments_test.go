@@ -0,0 +1,115 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseNewCommentsHTML tests that the comments of the newest
+// comments feed are extracted along with their stories and parents.
+func TestParseNewCommentsHTML(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_newcomments.html"))
+
+	assert.Nil(t, err)
+
+	comments, err := parser.ParseNewCommentsHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	type summary struct {
+		ID         int
+		Author     string
+		ParentID   int
+		StoryID    int
+		StoryTitle string
+	}
+
+	expected := []summary{
+		{ID: 8800412, Author: "latency_nerd", ParentID: 8800390, StoryID: 8800100, StoryTitle: "Tuning Postgres for write-heavy workloads"},
+		{ID: 8800411, Author: "quietlurker", ParentID: 8800250, StoryID: 8800250, StoryTitle: "Show HN: A terminal UI for browsing SQLite files"},
+		{ID: 8800409, Author: "latency_nerd", ParentID: 8800377, StoryID: 8800100, StoryTitle: "Tuning Postgres for write-heavy workloads"},
+	}
+
+	if assert.Len(t, comments, len(expected)) {
+		for i, comment := range comments {
+			if assert.NotNil(t, comment.ParentID) && assert.NotNil(t, comment.StoryID) {
+				assert.Equal(t, expected[i], summary{
+					ID:         comment.ID,
+					Author:     comment.Author,
+					ParentID:   *comment.ParentID,
+					StoryID:    *comment.StoryID,
+					StoryTitle: comment.StoryTitle,
+				})
+			}
+
+			// the comments of the feed are not nested
+			assert.Equal(t, 0, comment.Depth)
+
+			assert.Equal(t, 0, comment.ReplyCount)
+		}
+
+		assert.Equal(t, time.Date(2024, time.October, 14, 17, 45, 3, 0, time.UTC), comments[0].Date)
+
+		assert.Equal(t, "Tail latency is the number that matters here, not the median.", comments[0].PlainText())
+
+		assert.Equal(t, []string{"https://www.postgresql.org/docs/current/wal-configuration.html"}, comments[2].Links)
+	}
+
+	// the comments of a thread are found all the same
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample_threads.html"))
+
+	assert.Nil(t, err)
+
+	full, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	comments, err = parser.ParseNewCommentsHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, full.Comments, comments)
+
+	sample, err = os.ReadFile(filepath.Join("testdata", "sample_ratelimited.html"))
+
+	assert.Nil(t, err)
+
+	_, err = parser.ParseNewCommentsHTML(bytes.NewReader(sample))
+
+	assert.ErrorIs(t, err, parser.ErrRateLimited)
+}
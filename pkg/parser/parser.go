@@ -0,0 +1,609 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultElements specifies the element types that are
+// processed for data extraction by default.
+var defaultElements = []string{"td", "tr", "span", "a", "table"}
+
+// defaultBaseURL is the URL against which relative
+// references are resolved by default.
+var defaultBaseURL = &url.URL{Scheme: "https", Host: "news.ycombinator.com", Path: "/"}
+
+// defaultUserAgent is the User-Agent header set on the
+// requests of the fetch helpers by default.
+const defaultUserAgent = "hn-item-parser/2 (+https://github.com/TorNATO-PRO/hn-item-parser)"
+
+// defaultTimeout is the time allowed for each of the requests
+// of the fetch helpers by default.
+const defaultTimeout = 30 * time.Second
+
+// defaultParser is the Parser used by the package-level
+// convenience functions.
+var defaultParser = New()
+
+// Parser parses HN pages into the model types. A Parser holds no
+// mutable state, so it is safe to use from multiple goroutines.
+type Parser struct {
+	// elements is the set of element types
+	// that are processed for data extraction.
+	elements map[string]struct{}
+
+	// location is the location in which
+	// dates are interpreted.
+	location *time.Location
+
+	// skipComments determines whether the
+	// comments are skipped during traversal.
+	skipComments bool
+
+	// base is the URL against which relative
+	// references are resolved, if any.
+	base *url.URL
+
+	// strict determines whether missing
+	// required fields are reported.
+	strict bool
+
+	// maxComments is the maximum number of
+	// comments extracted, if positive.
+	maxComments int
+
+	// maxDepth is the maximum depth of the
+	// comments extracted, if not negative.
+	maxDepth int
+
+	// commentTree determines whether the reply tree
+	// of the comments is built as they are extracted.
+	commentTree bool
+
+	// now returns the current time, against which
+	// relative ages are resolved.
+	now func() time.Time
+
+	// rawHTML determines whether the raw HTML
+	// of each comment is kept.
+	rawHTML bool
+
+	// contentCleaner cleans the raw HTML of the
+	// content of each comment, if set.
+	contentCleaner func(rawHTML string) string
+
+	// userAgent and referer are the headers set
+	// on the requests of the fetch helpers.
+	userAgent string
+	referer   string
+
+	// timeout is the time allowed for each of the
+	// requests of the fetch helpers, if positive.
+	timeout time.Duration
+
+	// client performs the requests of the fetch helpers
+	// when none is provided to them, if set.
+	client *http.Client
+
+	// retries is the number of times a transient failure
+	// of the fetch helpers is retried, starting after
+	// backoff and doubling it on each retry.
+	retries int
+	backoff time.Duration
+
+	// classes are the class names of the
+	// markup from which fields are extracted.
+	classes ClassNames
+
+	// since is the cutoff at which the parsing
+	// of a listing stops, if set.
+	since time.Time
+
+	// maxBytes is the maximum size of a parsed
+	// document, if positive.
+	maxBytes int64
+
+	// lenientDates determines whether dates that
+	// cannot be parsed are tolerated.
+	lenientDates bool
+
+	// urlSanitizer rewrites the URLs of titles and
+	// comment links as they are extracted, if set.
+	urlSanitizer func(*url.URL) *url.URL
+
+	// stripTracking determines whether tracking
+	// parameters are stripped from the URLs ahead
+	// of the URL sanitizer.
+	stripTracking bool
+
+	// stats accumulates the statistics of
+	// the parsed documents, if set.
+	stats *Stats
+
+	// logger is notified of the extractions skipped
+	// for lack of an expected node, if set.
+	logger func(field string, reason string)
+}
+
+// ClassNames specifies the class names of the HN markup from which the Parser
+// extracts fields, allowing a Parser to follow HN when it renames a class.
+type ClassNames struct {
+	// Item is the class of the rows of items and
+	// comments (by default "athing").
+	Item string
+
+	// Comment is the class that distinguishes the rows
+	// of comments from other rows (by default "comtr").
+	Comment string
+
+	// PollOption is the class that distinguishes the rows
+	// of poll options from other rows (by default "pollopt").
+	PollOption string
+
+	// CommentTree is the class of the table holding
+	// the comments (by default "comment-tree").
+	CommentTree string
+
+	// CommentText is the class of the content of a
+	// comment or poll option (by default "commtext").
+	CommentText string
+
+	// TitleLine is the class of the title of an
+	// item (by default "titleline").
+	TitleLine string
+
+	// Subtext is the class of the cell below the
+	// title of an item (by default "subtext").
+	Subtext string
+
+	// Subline is the class of the score, author, and
+	// date of an item (by default "subline").
+	Subline string
+
+	// User is the class of the links to the authors of
+	// items and comments (by default "hnuser").
+	User string
+
+	// Age is the class of the dates of items and
+	// comments (by default "age").
+	Age string
+
+	// Score is the class of the scores of items and
+	// comments (by default "score").
+	Score string
+
+	// Site is the class of the site displayed next to
+	// the title of an item (by default "sitestr").
+	Site string
+
+	// SiteBit is the class of the span holding the site
+	// next to the title of an item (by default "sitebit").
+	SiteBit string
+
+	// Title is the class of the cells holding the rank
+	// and the title of an item (by default "title").
+	Title string
+
+	// Rank is the class of the rank of an item on
+	// a listing page (by default "rank").
+	Rank string
+
+	// FatItem is the class of the table holding an
+	// item on its own page (by default "fatitem").
+	FatItem string
+
+	// TopText is the class of the text of a
+	// self-post (by default "toptext").
+	TopText string
+
+	// PastLink is the class of the link to the past
+	// submissions of an item (by default "hnpast").
+	PastLink string
+
+	// MoreLink is the class of the link to the next
+	// page of a thread (by default "morelink").
+	MoreLink string
+
+	// Spacer is the class of the rows that separate the
+	// items of a listing (by default "spacer").
+	Spacer string
+
+	// Hidden is the class of an upvote arrow hidden
+	// after the viewer voted (by default "nosee").
+	Hidden string
+
+	// VoteLinks is the class of the cell holding the vote
+	// arrows of an item, and of the favicon of its site
+	// rendered beside them (by default "votelinks").
+	VoteLinks string
+
+	// Indent is the class of the cell whose spacer image
+	// indents a comment (by default "ind").
+	Indent string
+
+	// CommentCell is the class of the cell holding the
+	// header and the body of a comment (by default "default").
+	CommentCell string
+
+	// CommentHead is the class of the header of a comment,
+	// which the sitebit of an item also carries (by default
+	// "comhead").
+	CommentHead string
+
+	// CommentBody is the class of the body of a comment,
+	// below its header (by default "comment").
+	CommentBody string
+
+	// Navs is the class of the navigation links of a
+	// comment (by default "navs").
+	Navs string
+
+	// Toggle is the class of the link that collapses a
+	// comment (by default "togg clicky").
+	Toggle string
+
+	// OnStory is the class of the link to the story of a
+	// comment outside its thread (by default "onstory").
+	OnStory string
+
+	// Reply is the class of the reply link of a
+	// comment (by default "reply").
+	Reply string
+}
+
+// DefaultClassNames returns the class names of the current HN markup,
+// which are used by default. It returns a fresh copy on each call, which
+// may be patched and passed to WithClassNames.
+func DefaultClassNames() ClassNames {
+	return ClassNames{
+		Item:        "athing",
+		Comment:     "comtr",
+		PollOption:  "pollopt",
+		CommentTree: "comment-tree",
+		CommentText: "commtext",
+		TitleLine:   "titleline",
+		Subtext:     "subtext",
+		Subline:     "subline",
+		User:        "hnuser",
+		Age:         "age",
+		Score:       "score",
+		Site:        "sitestr",
+		SiteBit:     "sitebit",
+		Title:       "title",
+		Rank:        "rank",
+		FatItem:     "fatitem",
+		TopText:     "toptext",
+		PastLink:    "hnpast",
+		MoreLink:    "morelink",
+		Spacer:      "spacer",
+		Hidden:      "nosee",
+		VoteLinks:   "votelinks",
+		Indent:      "ind",
+		CommentCell: "default",
+		CommentHead: "comhead",
+		CommentBody: "comment",
+		Navs:        "navs",
+		Toggle:      "togg clicky",
+		OnStory:     "onstory",
+		Reply:       "reply",
+	}
+}
+
+// Option configures a Parser.
+type Option func(*Parser)
+
+// New creates a Parser configured with the provided options.
+func New(opts ...Option) *Parser {
+	p := &Parser{
+		location:  time.UTC,
+		base:      defaultBaseURL,
+		now:       time.Now,
+		userAgent: defaultUserAgent,
+		timeout:   defaultTimeout,
+		maxDepth:  -1,
+		classes:   DefaultClassNames(),
+	}
+
+	WithElements(defaultElements...)(p)
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// WithElements sets the element types (e.g. "td", "span") that are processed
+// for data extraction, replacing the default set of "td", "tr", "span", "a",
+// and "table".
+func WithElements(elements ...string) Option {
+	return func(p *Parser) {
+		p.elements = make(map[string]struct{}, len(elements))
+
+		for _, element := range elements {
+			p.elements[element] = struct{}{}
+		}
+	}
+}
+
+// WithTimeLocation sets the location in which the dates displayed by HN are
+// interpreted, replacing the default of UTC. When HN also reports the Unix
+// timestamp of an item or comment, the timestamp is authoritative and the
+// location only determines the location of the resulting time.Time. A nil
+// location selects UTC, as time.Time does.
+func WithTimeLocation(loc *time.Location) Option {
+	return func(p *Parser) {
+		if loc == nil {
+			loc = time.UTC
+		}
+
+		p.location = loc
+	}
+}
+
+// WithStrictParse sets whether parsing fails with ErrMissingFields when the
+// title, ID, or date of the item, or the score or author of an item other than
+// a job posting, cannot be found. The permalink page of a comment only requires
+// the ID and title of its story. By default, missing fields are left zeroed.
+func WithStrictParse(strict bool) Option {
+	return func(p *Parser) {
+		p.strict = strict
+	}
+}
+
+// WithMaxComments sets the maximum number of comments that are extracted from
+// a page. Once the limit is reached, the remaining comments are skipped and the
+// item is marked with CommentsTruncated, except by ParseHTMLStream, which returns
+// the item before the limit can be reached. A limit of zero, the default, extracts
+// every comment.
+func WithMaxComments(n int) Option {
+	return func(p *Parser) {
+		p.maxComments = n
+	}
+}
+
+// WithMaxDepth sets the maximum depth of the comments that are extracted from a
+// page, where top-level comments have a depth of 0, so that a depth of 1 keeps the
+// top-level comments and their direct replies. Deeper comments are skipped, using
+// their indentation alone, before any of their fields are extracted, and the item
+// is marked with CommentsTruncated, except by ParseHTMLStream. A negative depth,
+// the default, extracts every comment.
+func WithMaxDepth(n int) Option {
+	return func(p *Parser) {
+		p.maxDepth = n
+	}
+}
+
+// WithCommentTree sets whether ParseHTML and its variants also build the reply
+// tree of the comments into the CommentRoots of the item, as BuildCommentTree
+// would, attaching each comment to the tree as it is extracted rather than in a
+// second pass over the comments. The comments of the tree are copies of those of
+// the item. The tree is not built by default.
+func WithCommentTree(enabled bool) Option {
+	return func(p *Parser) {
+		p.commentTree = enabled
+	}
+}
+
+// WithComments sets whether the comments of a page are extracted. When disabled,
+// the comment tree is skipped entirely and the Comments of the item are left nil,
+// which is considerably faster when only the item metadata is needed. Comments are
+// extracted by default.
+func WithComments(enabled bool) Option {
+	return func(p *Parser) {
+		p.skipComments = !enabled
+	}
+}
+
+// WithBaseURL sets the URL against which relative references, such as the title
+// of a self-post, the links within comments, and the link to the next page of
+// comments, are resolved, replacing the default of https://news.ycombinator.com/.
+// A nil URL leaves relative references unresolved.
+func WithBaseURL(base *url.URL) Option {
+	return func(p *Parser) {
+		p.base = base
+	}
+}
+
+// WithNow sets the function that returns the current time, against which the
+// relative ages displayed by HN (e.g. "5 hours ago") are resolved when a date
+// is otherwise unavailable, replacing the default of time.Now. This is useful
+// for parsing saved pages as of the time at which they were saved.
+func WithNow(now func() time.Time) Option {
+	return func(p *Parser) {
+		p.now = now
+	}
+}
+
+// WithRawHTML sets whether the raw HTML of each comment is kept in its RawHTML,
+// as it appears on the page, alongside its cleaned Content. Unlike the content,
+// the raw HTML keeps its attributes and its whitespace, such as that of code
+// blocks. It is not kept by default, as it roughly doubles the memory held by
+// the comments.
+func WithRawHTML(enabled bool) Option {
+	return func(p *Parser) {
+		p.rawHTML = enabled
+	}
+}
+
+// WithCommentContentCleaner sets the function that cleans the content of each
+// comment, which receives the raw HTML of the content as it appears on the page
+// and returns the Content of the comment, such as plain text or markdown. A nil
+// cleaner restores the default, which renders the content as cleaned HTML.
+func WithCommentContentCleaner(cleaner func(rawHTML string) string) Option {
+	return func(p *Parser) {
+		p.contentCleaner = cleaner
+	}
+}
+
+// WithUserAgent sets the User-Agent header of the requests made by the fetch
+// helpers, such as ParseURL, replacing the default, which identifies this
+// package. HN may block requests bearing the default user agent of Go.
+func WithUserAgent(userAgent string) Option {
+	return func(p *Parser) {
+		p.userAgent = userAgent
+	}
+}
+
+// WithReferer sets the Referer header of the requests made by the fetch
+// helpers, such as ParseURL. By default, no Referer header is set.
+func WithReferer(referer string) Option {
+	return func(p *Parser) {
+		p.referer = referer
+	}
+}
+
+// WithTimeout sets the time allowed for each of the requests made by the fetch
+// helpers, such as ParseURL, from sending the request to parsing the response,
+// replacing the default of 30 seconds, so that a slow HN does not hang a caller
+// that passes a context without a deadline. A context that already carries a
+// deadline takes precedence, however distant. A timeout of zero or less
+// disables it.
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Parser) {
+		p.timeout = timeout
+	}
+}
+
+// WithHTTPClient sets the client that performs the requests made by the fetch
+// helpers, such as ParseURL, when they are not provided with a client of their own.
+// This allows a Parser to carry a client with its own transport, such as a proxy or
+// a cache, to every call. A nil client, the default, selects http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Parser) {
+		p.client = client
+	}
+}
+
+// WithRetries sets the number of times the fetch helpers, such as ParseURL, retry a
+// request that failed transiently, namely with a 5xx status or with ErrRateLimited,
+// as HN rate-limits aggressively. The first retry waits for the provided backoff,
+// which doubles on each following retry. Retries stop as soon as the context is done,
+// with an error wrapping both the context's error and that of the last attempt, and
+// are not attempted when the backoff would outlive the deadline of the context.
+// Other failures, such as a 404 status or ErrItemNotFound, are never retried. By
+// default, requests are not retried.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(p *Parser) {
+		p.retries = n
+		p.backoff = backoff
+	}
+}
+
+// WithClassNames sets the class names of the markup from which fields are
+// extracted, replacing the defaults returned by DefaultClassNames. This allows
+// a Parser to follow HN when it renames a class, without a new release.
+func WithClassNames(classes ClassNames) Option {
+	return func(p *Parser) {
+		p.classes = classes
+	}
+}
+
+// WithSince sets the cutoff at which the parsing of a listing, such as the newest
+// submissions, stops: ParseListing leaves out the first item older than the cutoff
+// and any item below it, and reports that the cutoff was reached. ParseListHTML
+// and ParseList leave out the same items, without reporting it. This allows a
+// poller to extract only the items submitted since its previous run. The zero time
+// disables the cutoff, which is the default.
+func WithSince(since time.Time) Option {
+	return func(p *Parser) {
+		p.since = since
+	}
+}
+
+// WithMaxBytes sets the maximum size, in bytes, of the documents that the Parser
+// reads, guarding services that parse untrusted content against documents large
+// enough to exhaust their memory. Parsing a larger document fails with an error
+// wrapping ErrDocumentTooLarge. A limit of zero or less, the default, disables it.
+func WithMaxBytes(n int64) Option {
+	return func(p *Parser) {
+		p.maxBytes = n
+	}
+}
+
+// WithLenientDates sets whether dates that cannot be parsed are tolerated. A
+// lenient Parser leaves the date of the item or comment zero and records the raw
+// date in its RawDate, rather than failing the parse of the whole page, so that
+// a single odd date does not lose the rest of a long thread. Dates are strict by
+// default.
+func WithLenientDates(enabled bool) Option {
+	return func(p *Parser) {
+		p.lenientDates = enabled
+	}
+}
+
+// WithURLSanitizer sets the function that rewrites the URLs that the Parser
+// extracts, namely the reference of the title of an item and the links within
+// comments, such as to normalize them before they are stored or deduplicated.
+// The sanitizer receives the resolved URL and returns the URL to keep, or nil to
+// drop it, and must not modify the URL it receives. A nil sanitizer, the default,
+// keeps the URLs as they appear on the page.
+func WithURLSanitizer(sanitizer func(*url.URL) *url.URL) Option {
+	return func(p *Parser) {
+		p.urlSanitizer = sanitizer
+	}
+}
+
+// WithStripTrackingParams sets whether the tracking parameters, such as "utm_source"
+// and "ref", are stripped from the URLs that the Parser extracts, as StripTrackingParams
+// does. The setting is independent of WithURLSanitizer: the parameters are stripped
+// before the URL sanitizer, if any, is applied, whatever the order of the options.
+// Tracking parameters are kept by default.
+func WithStripTrackingParams(enabled bool) Option {
+	return func(p *Parser) {
+		p.stripTracking = enabled
+	}
+}
+
+// WithStats sets the Stats in which the Parser accumulates statistics over the
+// documents parsed by ParseHTML and its variants, namely the number of nodes it
+// visits, the number of comments it extracts, the errors it encounters, and the
+// time it spends. A nil Stats, the default, disables the statistics.
+func WithStats(stats *Stats) Option {
+	return func(p *Parser) {
+		p.stats = stats
+	}
+}
+
+// WithLogger sets the function that the Parser notifies whenever it skips the
+// extraction of a field for lack of a node that the markup is expected to hold,
+// such as a subline without a score, or tolerates a date that cannot be parsed.
+// The field is named as in a *ParseError (e.g. "score"), and the reason describes
+// what was missing. This gives visibility into drifts of the HN markup without
+// turning them into errors. The logger may be called concurrently when the Parser
+// is used concurrently. A nil logger, the default, disables the notifications.
+func WithLogger(logger func(field string, reason string)) Option {
+	return func(p *Parser) {
+		p.logger = logger
+	}
+}
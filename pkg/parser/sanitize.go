@@ -0,0 +1,101 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// trackingParams specifies the query parameters that are stripped by
+// StripTrackingParams, along with those starting with "utm_".
+var trackingParams = []string{
+	"ref", "ref_src", "ref_url", "fbclid", "gclid", "dclid", "msclkid",
+	"yclid", "igshid", "mc_cid", "mc_eid", "_hsenc", "_hsmi",
+}
+
+// StripTrackingParams returns a copy of the provided URL without the query
+// parameters commonly used to track the source of a visit, such as "utm_source",
+// "ref", and "fbclid". The remaining parameters keep their order and encoding.
+// Returns the URL itself if it holds no tracking parameters, or nil if it is nil.
+func StripTrackingParams(u *url.URL) *url.URL {
+	if u == nil || u.RawQuery == "" {
+		return u
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+
+	kept := slices.DeleteFunc(slices.Clone(params), isTrackingParam)
+
+	if len(kept) == len(params) {
+		return u
+	}
+
+	stripped := *u
+	stripped.RawQuery = strings.Join(kept, "&")
+	stripped.ForceQuery = false
+
+	return &stripped
+}
+
+// isTrackingParam checks whether the provided raw query parameter, of the form
+// "key=value", is a tracking parameter. Returns true if its key starts with
+// "utm_" or is one of the trackingParams, false otherwise.
+func isTrackingParam(param string) bool {
+	key, _, _ := strings.Cut(param, "=")
+
+	if unescaped, err := url.QueryUnescape(key); err == nil {
+		key = unescaped
+	}
+
+	key = strings.ToLower(key)
+
+	return strings.HasPrefix(key, "utm_") || slices.Contains(trackingParams, key)
+}
+
+// sanitize rewrites the provided URL with the URL sanitizer of the Parser, after
+// stripping its tracking parameters if the Parser is set to. Returns the URL
+// untouched if neither applies or the URL is nil.
+func (p *Parser) sanitize(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+
+	if p.stripTracking {
+		u = StripTrackingParams(u)
+	}
+
+	if p.urlSanitizer == nil {
+		return u
+	}
+
+	return p.urlSanitizer(u)
+}
@@ -0,0 +1,118 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+)
+
+// unattributedError specifies the key under which the Stats count
+// the errors that are not attributed to a field, such as those of
+// error pages and cancelled contexts.
+const unattributedError = "other"
+
+// Stats accumulates statistics over the documents parsed by a Parser configured
+// with WithStats, which help to diagnose markup drift, such as a page yielding
+// fewer comments than expected. A Stats may be shared by concurrent parses, but
+// its fields must only be read once the parses that use it have returned.
+type Stats struct {
+	mu sync.Mutex
+
+	// Documents is the number of documents parsed.
+	Documents int
+
+	// NodesVisited is the number of HTML nodes visited
+	// while traversing the documents.
+	NodesVisited int
+
+	// CommentsExtracted is the number of comments
+	// extracted from the documents.
+	CommentsExtracted int
+
+	// Errors is the number of errors encountered, keyed
+	// by the field that failed to parse (e.g. "date"),
+	// or by "other" for errors not attributed to a field.
+	// Dates tolerated by a lenient Parser are counted.
+	Errors map[string]int
+
+	// Duration is the time spent parsing the documents.
+	Duration time.Duration
+}
+
+// recordParse records the parse of a document, which visited the provided
+// number of nodes in the provided duration and yielded the provided item, or
+// failed with the provided error.
+func (s *Stats) recordParse(visited int, item *model.Item, err error, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Documents++
+	s.NodesVisited += visited
+	s.Duration += duration
+
+	if item != nil {
+		s.CommentsExtracted += len(item.Comments)
+	}
+
+	if err != nil {
+		s.countError(err)
+	}
+}
+
+// recordError records the provided error, which was tolerated rather than
+// failing the parse.
+func (s *Stats) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.countError(err)
+}
+
+// countError counts the provided error under the field that failed to parse,
+// if any. The mutex of the Stats must be held.
+func (s *Stats) countError(err error) {
+	if s.Errors == nil {
+		s.Errors = make(map[string]int)
+	}
+
+	var parseErr *ParseError
+
+	if errors.As(err, &parseErr) {
+		s.Errors[parseErr.Field]++
+
+		return
+	}
+
+	s.Errors[unattributedError]++
+}
@@ -0,0 +1,170 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"context"
+	"io"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+)
+
+// ParseHTMLStream parses an HTML document from the provided io.Reader, returning
+// the item metadata once and streaming its comments. ParseHTMLStream uses a Parser
+// with the default options.
+func ParseHTMLStream(ctx context.Context, doc io.Reader) (*model.Item, <-chan model.Comment, <-chan error) {
+	return defaultParser.ParseHTMLStream(ctx, doc)
+}
+
+// ParseHTMLStream parses an HTML document from the provided io.Reader, returning
+// the item metadata (title, author, etc.) once, without its comments. The comments
+// are then pushed onto the returned comment channel in document order as they are
+// extracted. As each comment is sent before its replies are read, its ReplyCount
+// is only set where the markup shows it, and is otherwise left at zero, whereas
+// ParseHTML counts the replies parsed alongside the comment. Likewise, the item is
+// returned before any comment is extracted, so CommentsTruncated is never set on
+// it, even when WithMaxComments or WithMaxDepth leaves comments out; a consumer
+// that needs to know must use ParseHTML instead. Both channels are closed once
+// extraction completes; an error, including the context's error on cancellation,
+// is sent on the error channel beforehand. If the document cannot be parsed, the
+// returned item is nil and the comment channel is closed immediately.
+func (p *Parser) ParseHTMLStream(ctx context.Context, doc io.Reader) (*model.Item, <-chan model.Comment, <-chan error) {
+	comments := make(chan model.Comment)
+	errs := make(chan error, 1)
+
+	node, err := p.parseDocument(doc)
+
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	if err == nil {
+		err = p.checkErrorPage(node)
+	}
+
+	if err != nil {
+		errs <- err
+
+		close(comments)
+		close(errs)
+
+		return nil, comments, errs
+	}
+
+	var item model.Item
+
+	var visited int
+
+	var focused *model.Comment
+
+	metadataParser := *p
+	metadataParser.skipComments = true
+
+	err = func() (err error) {
+		defer recoverPanic(&err)
+
+		if err := metadataParser.walkNode(ctx, node, &item, NopVisitor{}, &visited); err != nil {
+			return err
+		}
+
+		inferKind(&item)
+
+		if focused, err = metadataParser.extractFocusedComment(node, &item); err != nil {
+			return err
+		}
+
+		if err := metadataParser.extractOpenGraph(node, &item); err != nil {
+			return err
+		}
+
+		extractPageTitle(node, &item)
+
+		return nil
+	}()
+
+	if err != nil {
+		errs <- err
+
+		close(comments)
+		close(errs)
+
+		return &item, comments, errs
+	}
+
+	go func() {
+		var err error
+
+		defer close(errs)
+		defer close(comments)
+
+		defer func() {
+			if err != nil {
+				errs <- err
+			}
+		}()
+
+		defer recoverPanic(&err)
+
+		send := func(comment *model.Comment) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			markOP(comment, item.Author)
+
+			select {
+			case comments <- *comment:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		// the focused comment of a permalink page
+		// leads its replies
+		if focused != nil {
+			if err = send(focused); err != nil {
+				return
+			}
+		}
+
+		treeNode := getChildRefByClass(node, p.classes.CommentTree)
+
+		_, err = p.visitComments(treeNode, func(comment *model.Comment, _ bool) error {
+			if focused != nil {
+				nestReply(comment, focused)
+			}
+
+			return send(comment)
+		})
+	}()
+
+	return &item, comments, errs
+}
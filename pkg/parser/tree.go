@@ -0,0 +1,114 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import "github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+
+// BuildCommentTree reconstructs the reply tree of the provided comments using
+// their ParentID pointers, preserving the order of the comments among siblings.
+// Comments whose parent is absent from the slice (including top-level comments)
+// are returned as roots.
+func BuildCommentTree(comments []model.Comment) []*model.CommentNode {
+	nodes := make(map[int]*model.CommentNode, len(comments))
+
+	for _, comment := range comments {
+		nodes[comment.ID] = &model.CommentNode{Comment: comment}
+	}
+
+	var roots []*model.CommentNode
+
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+
+		if comment.ParentID != nil && *comment.ParentID != comment.ID {
+			if parent, ok := nodes[*comment.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+
+				continue
+			}
+		}
+
+		roots = append(roots, node)
+	}
+
+	return roots
+}
+
+// FlattenCommentTree returns the comments of the provided reply tree, such as one
+// reconstructed by BuildCommentTree, in the order in which HN displays them: each
+// root is followed by its replies, in pre-order. For well-formed input, flattening
+// the tree built from a slice of comments yields the slice back.
+func FlattenCommentTree(roots []*model.CommentNode) []model.Comment {
+	var comments []model.Comment
+
+	for _, root := range roots {
+		comments = append(comments, root.Flatten()...)
+	}
+
+	return comments
+}
+
+// commentTreeBuilder builds the reply tree of comments as they are extracted, in
+// the order in which HN displays them, from their depth alone: the parent of each
+// comment is the last comment added at a lesser depth, if any.
+type commentTreeBuilder struct {
+	// roots are the comments that have no parent
+	// among those added.
+	roots []*model.CommentNode
+
+	// path is the chain of nodes from a root
+	// to the last node added.
+	path []*model.CommentNode
+}
+
+// add adds a copy of the provided comment to the tree, beneath its parent, and
+// returns the node holding it.
+func (b *commentTreeBuilder) add(comment model.Comment) *model.CommentNode {
+	node := &model.CommentNode{Comment: comment}
+
+	// the depth of a comment may skip levels, such as those
+	// skipped by WithMaxDepth, so it is compared rather than
+	// used as an index into the path
+	for len(b.path) > 0 && b.path[len(b.path)-1].Comment.Depth >= comment.Depth {
+		b.path = b.path[:len(b.path)-1]
+	}
+
+	if len(b.path) == 0 {
+		b.roots = append(b.roots, node)
+	} else {
+		parent := b.path[len(b.path)-1]
+
+		parent.Children = append(parent.Children, node)
+	}
+
+	b.path = append(b.path, node)
+
+	return node
+}
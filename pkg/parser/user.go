@@ -0,0 +1,179 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"golang.org/x/net/html"
+)
+
+// createdLayout specifies the layout of the "day" parameter
+// of the link behind the creation date of a profile.
+const createdLayout = "2006-01-02"
+
+// createdTextLayout specifies the layout of the creation
+// date displayed on a profile (e.g. "May 8, 2008").
+const createdTextLayout = "January 2, 2006"
+
+// ParseUserHTML parses a user profile page (e.g. "user?id=dchest") from the
+// provided io.Reader into a model.User. ParseUserHTML uses a Parser with the
+// default options.
+func ParseUserHTML(doc io.Reader) (*model.User, error) {
+	return defaultParser.ParseUserHTML(doc)
+}
+
+// ParseUserHTML parses a user profile page (e.g. "user?id=dchest") from the
+// provided io.Reader into a model.User. The profile is laid out as a table whose
+// rows pair a label (e.g. "karma:") with its value. Returns ErrUserNotFound if the
+// page holds no profile, and an error if the document or any of the values cannot
+// be parsed.
+func (p *Parser) ParseUserHTML(doc io.Reader) (user *model.User, err error) {
+	defer recoverPanic(&err)
+
+	node, err := p.parseDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.checkErrorPage(node); err != nil {
+		return nil, err
+	}
+
+	user = &model.User{}
+
+	var found bool
+
+	var rowErr error
+
+	traverseNode(node, func(n *html.Node) {
+		if rowErr != nil || n.Type != html.ElementNode || n.Data != "tr" {
+			return
+		}
+
+		labelNode := getFirstElementChild(n, "td")
+
+		if labelNode == nil {
+			return
+		}
+
+		valueNode := labelNode.NextSibling
+
+		for valueNode != nil && (valueNode.Type != html.ElementNode || valueNode.Data != "td") {
+			valueNode = valueNode.NextSibling
+		}
+
+		if valueNode == nil {
+			return
+		}
+
+		switch strings.TrimSpace(getText(labelNode)) {
+		case "user:":
+			found = true
+
+			user.Name = cleanText(getText(valueNode))
+		case "created:":
+			user.Created, rowErr = p.parseCreated(valueNode)
+		case "karma:":
+			user.Karma, rowErr = parseKarma(valueNode)
+		case "about:":
+			user.About, rowErr = renderChildren(valueNode)
+		}
+	})
+
+	if rowErr != nil {
+		return user, rowErr
+	}
+
+	if !found {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+// parseCreated parses the creation date of a profile from the provided value
+// cell, preferring the "day" parameter of the link it holds over its text, and
+// interprets it in the location of the Parser. Returns a *ParseError if neither
+// can be parsed.
+func (p *Parser) parseCreated(node *html.Node) (time.Time, error) {
+	if linkNode := getChildRefByData(node, "a"); linkNode != nil {
+		if ref, err := url.Parse(getAttr(linkNode, "href")); err == nil {
+			if created, err := time.ParseInLocation(createdLayout, ref.Query().Get("day"), p.location); err == nil {
+				return created, nil
+			}
+		}
+	}
+
+	createdText := strings.TrimSpace(cleanText(getText(node)))
+
+	created, err := time.ParseInLocation(createdTextLayout, createdText, p.location)
+
+	if err != nil {
+		return time.Time{}, &ParseError{Field: "created", Raw: createdText, Err: err}
+	}
+
+	return created, nil
+}
+
+// parseKarma parses the karma of a profile from the provided value cell.
+// Returns a *ParseError if the karma cannot be parsed.
+func parseKarma(node *html.Node) (int, error) {
+	karmaText := strings.TrimSpace(getText(node))
+
+	karma, err := strconv.Atoi(karmaText)
+
+	if err != nil {
+		return 0, &ParseError{Field: "karma", Raw: karmaText, Err: err}
+	}
+
+	return karma, nil
+}
+
+// renderChildren renders the children of the provided HTML node, keeping their
+// attributes, and returns the result with extraneous whitespace removed. Returns
+// an error if a child cannot be rendered.
+func renderChildren(node *html.Node) (string, error) {
+	var buf bytes.Buffer
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&buf, child); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.TrimSpace(fixText(buf.String())), nil
+}
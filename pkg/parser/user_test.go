@@ -0,0 +1,108 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseUserHTML tests that each field of a profile
+// is extracted.
+func TestParseUserHTML(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_user.html"))
+
+	assert.Nil(t, err)
+
+	user, err := parser.ParseUserHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "dchest", user.Name)
+
+	assert.Equal(t, 23817, user.Karma)
+
+	assert.Equal(t, time.Date(2008, time.May, 8, 0, 0, 0, 0, time.UTC), user.Created)
+
+	assert.Equal(t, `Cryptography &amp; Go.<p>Writing at <a href="https://dchest.example.com" rel="nofollow">dchest.example.com</a></p>`, user.About)
+}
+
+// TestParseUserHTMLCreatedText tests that the creation date
+// falls back to its text when it is not a link.
+func TestParseUserHTMLCreatedText(t *testing.T) {
+	doc := `<table><tr class="athing" id="x"><td>user:</td><td><a href="user?id=x" class="hnuser">x</a></td></tr>` +
+		`<tr><td>created:</td><td>May 8, 2008</td></tr><tr><td>karma:</td><td>1</td></tr></table>`
+
+	user, err := parser.ParseUserHTML(strings.NewReader(doc))
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, time.Date(2008, time.May, 8, 0, 0, 0, 0, time.UTC), user.Created)
+
+	assert.Equal(t, "", user.About)
+}
+
+// TestParseUserHTMLMalformedKarma tests that malformed karma
+// is reported as a parser.ParseError.
+func TestParseUserHTMLMalformedKarma(t *testing.T) {
+	doc := `<table><tr class="athing" id="x"><td>user:</td><td>x</td></tr><tr><td>karma:</td><td>lots</td></tr></table>`
+
+	_, err := parser.ParseUserHTML(strings.NewReader(doc))
+
+	var parseErr *parser.ParseError
+
+	if assert.True(t, errors.As(err, &parseErr)) {
+		assert.Equal(t, "karma", parseErr.Field)
+
+		assert.Equal(t, "lots", parseErr.Raw)
+	}
+}
+
+// TestParseUserHTMLNotFound tests that a page without a
+// profile is reported.
+func TestParseUserHTMLNotFound(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample_nouser.html"))
+
+	assert.Nil(t, err)
+
+	user, err := parser.ParseUserHTML(bytes.NewReader(sample))
+
+	assert.ErrorIs(t, err, parser.ErrUserNotFound)
+
+	assert.Nil(t, user)
+}
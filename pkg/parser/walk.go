@@ -0,0 +1,364 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"golang.org/x/net/html"
+)
+
+// Visitor is called by Walk as the traversal of a page encounters the
+// relevant nodes. Each method is called in document order, and an error
+// returned by any of them stops the traversal, being returned by Walk.
+type Visitor interface {
+	// VisitNode is called with each node of the element
+	// types the Parser was configured with, before any
+	// field is extracted from it, allowing the extraction
+	// of fields that the model does not have. The nodes
+	// of the comments are visited after the comments.
+	VisitNode(node *html.Node) error
+
+	// VisitTitle is called with the title of the item.
+	VisitTitle(title model.Title) error
+
+	// VisitScore is called with the score of the item.
+	VisitScore(points int) error
+
+	// VisitAuthor is called with the author of the item.
+	VisitAuthor(author string) error
+
+	// VisitDate is called with the date of the item.
+	VisitDate(date time.Time) error
+
+	// VisitComment is called with each comment, unless
+	// the comments are skipped by the Parser. As its
+	// replies are yet to be read, its ReplyCount is
+	// only set where the markup shows it, and is
+	// otherwise left at zero, unlike with ParseHTML.
+	VisitComment(comment model.Comment) error
+}
+
+// NopVisitor is a Visitor whose methods do nothing. It may be embedded
+// in a Visitor that only needs to implement some of the methods.
+type NopVisitor struct{}
+
+// VisitNode does nothing.
+func (NopVisitor) VisitNode(*html.Node) error { return nil }
+
+// VisitTitle does nothing.
+func (NopVisitor) VisitTitle(model.Title) error { return nil }
+
+// VisitScore does nothing.
+func (NopVisitor) VisitScore(int) error { return nil }
+
+// VisitAuthor does nothing.
+func (NopVisitor) VisitAuthor(string) error { return nil }
+
+// VisitDate does nothing.
+func (NopVisitor) VisitDate(time.Time) error { return nil }
+
+// VisitComment does nothing.
+func (NopVisitor) VisitComment(model.Comment) error { return nil }
+
+// Walk parses an HTML document from the provided io.Reader and calls the
+// provided Visitor as the traversal encounters the relevant nodes. Walk uses
+// a Parser with the default options.
+func Walk(doc io.Reader, visitor Visitor) error {
+	return defaultParser.Walk(doc, visitor)
+}
+
+// Walk parses an HTML document from the provided io.Reader and calls the
+// provided Visitor as the traversal encounters the relevant nodes, rather than
+// populating a model.Item. The traversal is the one that ParseHTML runs, so the
+// fields are extracted exactly as ParseHTML does, including the comments of the
+// original poster being marked. Returns an error if the document cannot be parsed,
+// if any of the fields cannot be extracted, or if the Visitor returns one.
+func (p *Parser) Walk(doc io.Reader, visitor Visitor) (err error) {
+	defer recoverPanic(&err)
+
+	node, err := p.parseDocument(doc)
+	if err != nil {
+		return err
+	}
+
+	if err := p.checkErrorPage(node); err != nil {
+		return err
+	}
+
+	var visited int
+
+	return p.walkNode(context.Background(), node, &model.Item{}, visitor, &visited)
+}
+
+// walkNode recursively traverses an HTML node tree, extracting the fields of each
+// node that meets specific criteria into the provided model.Item, and calling the
+// provided Visitor with each field found and each comment. As the fields of the
+// whole page accumulate in the item, the extraction of a node may depend on those
+// found before it, such as the ID of the item or its author. Within the comments,
+// which are visited as a whole, the nodes are only passed to VisitNode. The context
+// is checked every ctxCheckInterval nodes, tracked through visited. Returns an error
+// if any of the fields cannot be extracted, if the Visitor returns one, or if the
+// context is done.
+func (p *Parser) walkNode(ctx context.Context, node *html.Node, item *model.Item, visitor Visitor, visited *int) error {
+	return p.walkNodeIn(ctx, node, item, visitor, visited, false)
+}
+
+// walkNodeIn behaves like walkNode, noting whether the provided HTML node lies
+// within the comments, which were already visited.
+func (p *Parser) walkNodeIn(ctx context.Context, node *html.Node, item *model.Item, visitor Visitor, visited *int, inComments bool) error {
+	*visited++
+
+	if *visited%ctxCheckInterval == 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	if node.Type == html.ElementNode && p.shouldProcess(node) {
+		if err := visitor.VisitNode(node); err != nil {
+			return err
+		}
+
+		switch {
+		case inComments:
+			// the comments were already visited
+		case classIs(node, p.classes.CommentTree):
+			// don't descend into the comments when they are skipped
+			if p.skipComments {
+				return nil
+			}
+
+			if err := p.walkComments(node, item, visitor); err != nil {
+				return err
+			}
+
+			inComments = true
+		default:
+			if err := p.walkFields(node, item, visitor); err != nil {
+				return err
+			}
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if err := p.walkNodeIn(ctx, child, item, visitor, visited, inComments); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkComments extracts the comments of the provided "comment-tree" HTML node,
+// marking those of the original poster of the provided model.Item, and calls the
+// provided Visitor with each of them. Returns an error if any of the comments
+// cannot be extracted, or if the Visitor returns one.
+func (p *Parser) walkComments(node *html.Node, item *model.Item, visitor Visitor) error {
+	truncated, err := p.visitComments(node, func(comment *model.Comment, shown bool) error {
+		markOP(comment, item.Author)
+
+		if counter, ok := visitor.(shownReplyCounter); ok && shown {
+			counter.shownReplyCount(comment.ID)
+		}
+
+		return visitor.VisitComment(*comment)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	item.CommentsTruncated = item.CommentsTruncated || truncated
+
+	return nil
+}
+
+// walkFields extracts the fields of the provided HTML node into the provided
+// model.Item, and calls the provided Visitor with those that the node yielded.
+// Returns an error if any of the fields cannot be extracted, or if the Visitor
+// returns one.
+func (p *Parser) walkFields(node *html.Node, item *model.Item, visitor Visitor) error {
+	found := item.Found
+
+	if err := p.processNode(node, item); err != nil {
+		return err
+	}
+
+	if item.Found.Title && !found.Title {
+		if err := visitor.VisitTitle(item.Title); err != nil {
+			return err
+		}
+	}
+
+	if item.Found.Score && !found.Score {
+		if err := visitor.VisitScore(item.Points); err != nil {
+			return err
+		}
+	}
+
+	if item.Found.Author && !found.Author {
+		if err := visitor.VisitAuthor(item.Author); err != nil {
+			return err
+		}
+	}
+
+	if item.Found.Date && !found.Date {
+		if err := visitor.VisitDate(item.Date); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shownReplyCounter is implemented by the visitors of the Parser that derive the
+// number of replies to each comment, which are told of the comments whose markup
+// shows it instead.
+type shownReplyCounter interface {
+	// shownReplyCount is called with the ID of each
+	// comment whose markup shows its number of replies.
+	shownReplyCount(id int)
+}
+
+// itemBuilder is the Visitor through which ParseHTML collects the comments of the
+// item whose other fields the traversal extracts, building their reply tree as they
+// are visited, and derives the fields that depend on all of the comments.
+type itemBuilder struct {
+	NopVisitor
+
+	// p is the Parser running the traversal.
+	p *Parser
+
+	// hasComments is set once the
+	// comments are reached.
+	hasComments bool
+
+	// comments are the comments
+	// visited so far.
+	comments []model.Comment
+
+	// nodes are the nodes of the comments
+	// within the tree, in the same order.
+	nodes []*model.CommentNode
+
+	// tree is the reply tree of
+	// the comments visited so far.
+	tree commentTreeBuilder
+
+	// focused is the comment that a permalink
+	// page focuses on, if any, beneath which
+	// the comments are nested.
+	focused *model.Comment
+
+	// shown are the IDs of the comments whose
+	// markup shows their number of replies.
+	shown map[int]bool
+}
+
+// VisitNode notes whether the comments of the page are reached.
+func (b *itemBuilder) VisitNode(node *html.Node) error {
+	if classIs(node, b.p.classes.CommentTree) && !b.p.skipComments {
+		b.hasComments = true
+	}
+
+	return nil
+}
+
+// VisitComment collects the provided comment, nesting it beneath the focused
+// comment, if any.
+func (b *itemBuilder) VisitComment(comment model.Comment) error {
+	if b.focused != nil {
+		nestReply(&comment, b.focused)
+	}
+
+	b.add(comment)
+
+	return nil
+}
+
+// shownReplyCount notes that the markup of the comment with the provided ID shows
+// its number of replies, which is then kept.
+func (b *itemBuilder) shownReplyCount(id int) {
+	b.shown[id] = true
+}
+
+// focus places the provided focused comment at the top of the thread, ahead of
+// the comments to be visited, which are its replies, noting whether its markup
+// shows its number of replies. The replies are laid out as though the focused
+// comment were the top of the thread, so they are nested beneath it: their depth
+// is shifted by one, and those at the top are given it as their parent.
+func (b *itemBuilder) focus(focused model.Comment, shown bool) {
+	b.hasComments = true
+
+	b.add(focused)
+
+	b.focused = &focused
+
+	if shown {
+		b.shownReplyCount(focused.ID)
+	}
+}
+
+// add collects the provided comment and adds it to the reply tree.
+func (b *itemBuilder) add(comment model.Comment) {
+	b.comments = append(b.comments, comment)
+	b.nodes = append(b.nodes, b.tree.add(comment))
+}
+
+// build assigns the collected comments to the provided model.Item, along with
+// their reply counts, the participants of the thread, the number of top-level
+// comments, and the reply tree of the comments when requested. Items whose page
+// has no comments are left untouched.
+func (b *itemBuilder) build(item *model.Item) {
+	if !b.hasComments {
+		return
+	}
+
+	// the replies of a comment are only known
+	// once every comment has been visited
+	for i, node := range b.nodes {
+		if !b.shown[node.Comment.ID] {
+			node.Comment.ReplyCount = len(node.Children)
+			b.comments[i].ReplyCount = node.Comment.ReplyCount
+		}
+	}
+
+	if b.p.commentTree {
+		item.CommentRoots = b.tree.roots
+	}
+
+	item.Comments = b.comments
+	item.Participants = collectParticipants(item)
+	item.TopLevelCommentCount = countTopLevelComments(item)
+}
@@ -0,0 +1,235 @@
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Nathan Waltz
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+// 1. Redistributions of source code must retain the above copyright notice, this
+//	list of conditions and the following disclaimer.
+//
+// 2. Redistributions in binary form must reproduce the above copyright notice,
+//	this list of conditions and the following disclaimer in the documentation
+//	and/or other materials provided with the distribution.
+//
+// 3. Neither the name of the copyright holder nor the names of its
+//	contributors may be used to endorse or promote products derived from
+//	this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+package parser_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/model"
+	"github.com/TorNATO-PRO/hn-item-parser/v2/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+// collectingVisitor records everything it visits, along
+// with the number of vote arrows on the page.
+type collectingVisitor struct {
+	parser.NopVisitor
+
+	title      model.Title
+	points     int
+	author     string
+	date       time.Time
+	comments   []model.Comment
+	voteArrows int
+}
+
+func (v *collectingVisitor) VisitNode(node *html.Node) error {
+	for _, attr := range node.Attr {
+		if attr.Key == "class" && attr.Val == "votearrow" {
+			v.voteArrows++
+		}
+	}
+
+	return nil
+}
+
+func (v *collectingVisitor) VisitTitle(title model.Title) error {
+	v.title = title
+
+	return nil
+}
+
+func (v *collectingVisitor) VisitScore(points int) error {
+	v.points = points
+
+	return nil
+}
+
+func (v *collectingVisitor) VisitAuthor(author string) error {
+	v.author = author
+
+	return nil
+}
+
+func (v *collectingVisitor) VisitDate(date time.Time) error {
+	v.date = date
+
+	return nil
+}
+
+func (v *collectingVisitor) VisitComment(comment model.Comment) error {
+	v.comments = append(v.comments, comment)
+
+	return nil
+}
+
+// TestWalk tests that walking a page visits the same fields
+// that parsing it extracts.
+func TestWalk(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+	assert.Nil(t, err)
+
+	visitor := &collectingVisitor{}
+
+	err = parser.Walk(bytes.NewReader(sample), visitor)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, parsed.Title, visitor.title)
+
+	assert.Equal(t, parsed.Points, visitor.points)
+
+	assert.Equal(t, parsed.Author, visitor.author)
+
+	assert.Equal(t, parsed.Date, visitor.date)
+
+	// a comment is visited before its replies are read, so
+	// unlike ParseHTML, it does not count them
+	if assert.Len(t, visitor.comments, len(parsed.Comments)) {
+		for i := range visitor.comments {
+			assert.Zero(t, visitor.comments[i].ReplyCount)
+
+			visitor.comments[i].ReplyCount = parsed.Comments[i].ReplyCount
+		}
+	}
+
+	assert.Equal(t, parsed.Comments, visitor.comments)
+
+	// the div elements are only visited
+	// when the Parser is configured to
+	assert.Equal(t, 0, visitor.voteArrows)
+
+	visitor = &collectingVisitor{}
+
+	p := parser.New(parser.WithElements("td", "tr", "span", "a", "table", "div"))
+
+	err = p.Walk(bytes.NewReader(sample), visitor)
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, 119, visitor.voteArrows)
+}
+
+// TestWalkContext tests that the fields visited depend on those found
+// before them, as they do when parsing: the scores of poll options are
+// told apart from that of the item, and the comments of the original
+// poster are marked.
+func TestWalkContext(t *testing.T) {
+	tests := []struct {
+		Testfile string
+		Testname string
+	}{
+		{
+			Testfile: filepath.Join("testdata", "sample_poll_subline.html"),
+			Testname: "TestPollOptionScores",
+		},
+		{
+			Testfile: filepath.Join("testdata", "sample_authored.html"),
+			Testname: "TestOriginalPoster",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Testname, func(t *testing.T) {
+			sample, err := os.ReadFile(test.Testfile)
+
+			assert.Nil(t, err)
+
+			parsed, err := parser.ParseHTML(bytes.NewReader(sample))
+
+			assert.Nil(t, err)
+
+			visitor := &collectingVisitor{}
+
+			err = parser.Walk(bytes.NewReader(sample), visitor)
+
+			assert.Nil(t, err)
+
+			assert.Equal(t, parsed.Points, visitor.points)
+
+			assert.Equal(t, parsed.Author, visitor.author)
+
+			// a comment is visited before its replies are read, so
+			// unlike ParseHTML, it does not count them
+			if assert.Len(t, visitor.comments, len(parsed.Comments)) {
+				for i := range visitor.comments {
+					assert.Zero(t, visitor.comments[i].ReplyCount)
+
+					visitor.comments[i].ReplyCount = parsed.Comments[i].ReplyCount
+				}
+			}
+
+			assert.Equal(t, parsed.Comments, visitor.comments)
+		})
+	}
+}
+
+// stoppingVisitor stops the traversal at the first comment.
+type stoppingVisitor struct {
+	parser.NopVisitor
+
+	comments int
+}
+
+// errStop is returned by the stoppingVisitor.
+var errStop = errors.New("stop")
+
+func (v *stoppingVisitor) VisitComment(model.Comment) error {
+	v.comments++
+
+	return errStop
+}
+
+// TestWalkStop tests that an error returned by the visitor
+// stops the traversal and is returned by Walk.
+func TestWalkStop(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample1.html"))
+
+	assert.Nil(t, err)
+
+	visitor := &stoppingVisitor{}
+
+	err = parser.Walk(bytes.NewReader(sample), visitor)
+
+	assert.ErrorIs(t, err, errStop)
+
+	assert.Equal(t, 1, visitor.comments)
+}